@@ -0,0 +1,110 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CertificatePolicy modes
+const (
+	// CertificatePolicyEnforce rejects the Certificates violating the policy
+	CertificatePolicyEnforce = "Enforce"
+	// CertificatePolicyAudit admits the Certificates violating the policy
+	// with a warning
+	CertificatePolicyAudit = "Audit"
+)
+
+// CertificatePolicySpec defines the rules Certificates must follow. Rules not
+// set are not checked
+type CertificatePolicySpec struct {
+	// Mode is Enforce to reject the Certificates violating the policy, or
+	// Audit to admit them with a warning. Defaults to Enforce. While a policy
+	// is in Enforce mode, Certificates outside the namespace of the operator
+	// can not be created or updated when the operator is unavailable
+	// +kubebuilder:validation:Enum=Enforce;Audit
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// NamespaceSelector selects the namespaces of the Certificates the policy
+	// applies to. Defaults to all namespaces
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// MinRSAKeySize is the minimum size of RSA private keys
+	// +kubebuilder:validation:Minimum=1024
+	// +optional
+	MinRSAKeySize *int `json:"minRSAKeySize,omitempty"`
+
+	// AllowedECDSAKeySizes lists the allowed sizes of ECDSA private keys
+	// +optional
+	AllowedECDSAKeySizes []int `json:"allowedECDSAKeySizes,omitempty"`
+
+	// AllowedKeyAlgorithms lists the allowed private key algorithms
+	// +optional
+	AllowedKeyAlgorithms []string `json:"allowedKeyAlgorithms,omitempty"`
+
+	// MaxDuration is the maximum duration of Certificates
+	// +optional
+	MaxDuration *metav1.Duration `json:"maxDuration,omitempty"`
+
+	// AllowedIssuers lists the issuers the Certificates of the selected
+	// namespaces may reference
+	// +optional
+	AllowedIssuers []CertificatePolicyIssuer `json:"allowedIssuers,omitempty"`
+
+	// ForbidWildcardDNSNames rejects the Certificates with a wildcard DNS name
+	// +optional
+	ForbidWildcardDNSNames bool `json:"forbidWildcardDNSNames,omitempty"`
+}
+
+// CertificatePolicyIssuer references an Issuer or ClusterIssuer
+type CertificatePolicyIssuer struct {
+	// Kind is Issuer or ClusterIssuer. Defaults to Issuer
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// Name of the issuer, * allows any issuer of the kind
+	Name string `json:"name"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=certificatepolicies,scope=Cluster
+//+kubebuilder:printcolumn:name="Mode",type=string,JSONPath=`.spec.mode`
+
+// CertificatePolicy is the Schema for the certificatepolicies API. The
+// policies are evaluated by a validating webhook served by the operator when
+// Certificates are created or updated
+type CertificatePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CertificatePolicySpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// CertificatePolicyList contains a list of CertificatePolicy
+type CertificatePolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CertificatePolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CertificatePolicy{}, &CertificatePolicyList{})
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -28,6 +29,11 @@ type CertManagerConfigSpec struct {
 	// ImageRegistry describes the image registry for the operands, e.g.
 	// cert-manager-controller
 	ImageRegistry string `json:"imageRegistry,omitempty"`
+	// Profile selects a tested preset of resource requests and limits for
+	// all operands. Resources set on a component override the preset
+	// +kubebuilder:validation:Enum=small;medium;large
+	// +optional
+	Profile string `json:"profile,omitempty"`
 	// ImagePostFix describes a string that will be appended to the end of the
 	// fully qualified image, e.g. imageRegistry/imageName:imageTagAndPostFix
 	ImagePostFix string `json:"imagePostFix,omitempty"`
@@ -51,6 +57,11 @@ type CertManagerConfigSpec struct {
 	//ConfigMapWatcher is not used
 	ConfigMapWatcher CertManagerContainerSpec `json:"configMapWatcher,omitempty"`
 
+	// WebhookTLS configures how the serving certificate of
+	// cert-manager-webhook is issued
+	// +optional
+	WebhookTLS *WebhookTLSSpec `json:"webhookTLS,omitempty"`
+
 	// EnableCertRefresh enables the refresh of leaf certificates based on a CA
 	// certificate
 	EnableCertRefresh *bool `json:"enableCertRefresh,omitempty"`
@@ -60,6 +71,43 @@ type CertManagerConfigSpec struct {
 	// the CA is refreshed
 	RefreshCertsBasedOnCA []CACertificate `json:"refreshCertsBasedOnCA,omitempty"`
 
+	// WatchNamespaces restricts cert-manager-controller to the listed
+	// namespaces. Its permissions are granted with Roles in those namespaces
+	// instead of ClusterRoles, and cluster scoped features such as
+	// ClusterIssuers are disabled. cert-manager-controller can currently
+	// watch a single namespace. Empty means all namespaces
+	// +kubebuilder:validation:MaxItems=1
+	// +optional
+	WatchNamespaces []string `json:"watchNamespaces,omitempty"`
+
+	// EnableAggregatedClusterRoles creates ClusterRoles aggregated into the
+	// built-in admin, edit and view ClusterRoles, granting namespace users
+	// access to the cert-manager.io and acme.cert-manager.io resources.
+	// Defaults to true
+	// +optional
+	EnableAggregatedClusterRoles *bool `json:"enableAggregatedClusterRoles,omitempty"`
+
+	// NetworkPolicy configures the NetworkPolicies generated for the operands
+	// +optional
+	NetworkPolicy *NetworkPolicySpec `json:"networkPolicy,omitempty"`
+
+	// Monitoring configures the metrics Services and ServiceMonitors of the
+	// operands. The metrics Service of cert-manager-controller is always
+	// created
+	// +optional
+	Monitoring *MonitoringSpec `json:"monitoring,omitempty"`
+
+	// DefaultIssuers makes the operator reconcile a working internal issuer:
+	// a self-signed ClusterIssuer, a CA Certificate it issues and a CA
+	// ClusterIssuer signing with it
+	// +optional
+	DefaultIssuers *DefaultIssuersSpec `json:"defaultIssuers,omitempty"`
+
+	// ExpiryMonitoring configures the monitoring of the expiry of the
+	// Certificates and TLS secrets of the cluster. It is enabled by default
+	// +optional
+	ExpiryMonitoring *ExpiryMonitoringSpec `json:"expiryMonitoring,omitempty"`
+
 	// Labels describes  foundational services will use this
 	// labels to labels their corresponding resources
 	Labels map[string]string `json:"labels,omitempty"`
@@ -67,6 +115,13 @@ type CertManagerConfigSpec struct {
 	// +optional
 	License LicenseAcceptance `json:"license,omitempty"`
 
+	// Observability configures the telemetry exported by the operands
+	// +optional
+	Observability *ObservabilitySpec `json:"observability,omitempty"`
+
+	// EnableInstanaMetricCollection is deprecated, set
+	// observability.provider to instana instead. It is ignored when
+	// observability is set
 	// +optional
 	EnableInstanaMetricCollection bool `json:"enableInstanaMetricCollection,omitempty"`
 }
@@ -90,6 +145,287 @@ type LicenseAcceptance struct {
 
 type CertManagerContainerSpec struct {
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// LogLevel sets the log verbosity of the operand, rendered as the --v
+	// argument
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	LogLevel *int32 `json:"logLevel,omitempty"`
+	// LogFormat sets the log format of the operand, rendered as the
+	// --logging-format argument. Defaults to text
+	// +kubebuilder:validation:Enum=text;json
+	// +optional
+	LogFormat string `json:"logFormat,omitempty"`
+	// Strategy describes how the operand pods are replaced on a rollout.
+	// Defaults to a rolling update, or to maxSurge=0 when the operand runs on
+	// the host network
+	// +optional
+	Strategy *appsv1.DeploymentStrategy `json:"strategy,omitempty"`
+	// RevisionHistoryLimit is the number of old ReplicaSets kept for the
+	// operand deployment. Defaults to 10
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+	// Autoscaling configures autoscaling of the operand. A
+	// HorizontalPodAutoscaler is created for cert-manager-webhook, and a
+	// VerticalPodAutoscaler is created for cert-manager-controller and
+	// cert-manager-cainjector when the VerticalPodAutoscaler API is installed
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+	// Admission configures the mutating and validating webhook
+	// configurations of cert-manager-webhook. Only used for
+	// certManagerWebhook
+	// +optional
+	Admission *WebhookAdmissionSpec `json:"admission,omitempty"`
+}
+
+// WebhookAdmissionSpec describes how the API server calls cert-manager-webhook.
+// It applies to both the mutating and the validating webhook configurations
+type WebhookAdmissionSpec struct {
+	// FailurePolicy defines how errors calling the webhook are handled.
+	// Defaults to Fail
+	// +kubebuilder:validation:Enum=Fail;Ignore
+	// +optional
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+	// TimeoutSeconds is the timeout of the calls to the webhook. Defaults to
+	// 10
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=30
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+	// NamespaceSelector is added to the namespace selector of the webhooks,
+	// e.g. to exclude kube-system. Namespaces with the
+	// cert-manager.io/disable-validation=true label are always excluded from
+	// validation
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// ObjectSelector restricts the webhooks to the objects with matching
+	// labels
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+	// CircuitBreaker relaxes the webhooks while cert-manager-webhook is down,
+	// so that cert-manager resources can still be created and updated
+	// +optional
+	CircuitBreaker *WebhookCircuitBreakerSpec `json:"circuitBreaker,omitempty"`
+	// SelfTestNamespace is the namespace of the Certificates created with
+	// server-side dry-run to test the webhooks end to end. It must be
+	// selected by the webhooks. Defaults to default
+	// +optional
+	SelfTestNamespace string `json:"selfTestNamespace,omitempty"`
+}
+
+// WebhookCircuitBreakerSpec describes how the webhooks are relaxed while
+// cert-manager-webhook has no ready endpoints. The admission settings are
+// restored once the endpoints are ready
+type WebhookCircuitBreakerSpec struct {
+	// Enabled turns on the circuit breaker
+	Enabled bool `json:"enabled,omitempty"`
+	// OutageDuration is how long cert-manager-webhook has no ready endpoints
+	// before the webhooks are relaxed. Defaults to 5m
+	// +optional
+	OutageDuration *metav1.Duration `json:"outageDuration,omitempty"`
+	// Action is how the webhooks are relaxed. Ignore sets their failure
+	// policy to Ignore, ScopeOut sets a namespace selector and an object
+	// selector matching no namespace and no object, including cluster-scoped
+	// ones. Defaults to Ignore
+	// +kubebuilder:validation:Enum=Ignore;ScopeOut
+	// +optional
+	Action string `json:"action,omitempty"`
+}
+
+// AutoscalingSpec describes how an operand is autoscaled. While autoscaling is
+// enabled, the operator stops overwriting the replicas or resources owned by
+// the autoscaler
+// +kubebuilder:validation:XValidation:rule="!has(self.minReplicas) || !has(self.maxReplicas) || self.minReplicas <= self.maxReplicas",message="minReplicas must not be greater than maxReplicas"
+type AutoscalingSpec struct {
+	// Enabled turns on autoscaling for the operand
+	Enabled bool `json:"enabled,omitempty"`
+	// MinReplicas is the lower limit of replicas set by the
+	// HorizontalPodAutoscaler. Defaults to 1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the upper limit of replicas set by the
+	// HorizontalPodAutoscaler. Defaults to 3
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+	// TargetCPUUtilizationPercentage is the average CPU utilization the
+	// HorizontalPodAutoscaler scales on. Defaults to 80
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+	// UpdateMode is the update mode of the VerticalPodAutoscaler. Defaults to
+	// Auto
+	// +kubebuilder:validation:Enum=Off;Initial;Recreate;Auto
+	// +optional
+	UpdateMode string `json:"updateMode,omitempty"`
+	// MinAllowed is the lower limit of resources recommended by the
+	// VerticalPodAutoscaler
+	// +optional
+	MinAllowed corev1.ResourceList `json:"minAllowed,omitempty"`
+	// MaxAllowed is the upper limit of resources recommended by the
+	// VerticalPodAutoscaler
+	// +optional
+	MaxAllowed corev1.ResourceList `json:"maxAllowed,omitempty"`
+}
+
+// NetworkPolicySpec describes the NetworkPolicies generated for the operands,
+// for clusters that deny all traffic by default
+type NetworkPolicySpec struct {
+	// Enabled turns on the generation of NetworkPolicies for the operands
+	Enabled bool `json:"enabled,omitempty"`
+	// APIServerCIDRs restricts the egress of the operands to the API server
+	// to the listed CIDRs. Any destination is allowed on the API server ports
+	// when empty
+	// +optional
+	APIServerCIDRs []string `json:"apiServerCIDRs,omitempty"`
+	// EgressCIDRs are the CIDRs of external endpoints cert-manager-controller
+	// needs to reach, such as ACME servers or Vault
+	// +optional
+	EgressCIDRs []string `json:"egressCIDRs,omitempty"`
+	// MetricsNamespace is the namespace allowed to scrape the metrics of the
+	// operands, e.g. openshift-monitoring
+	// +optional
+	MetricsNamespace string `json:"metricsNamespace,omitempty"`
+}
+
+// MonitoringSpec describes how the metrics of the operands are exposed
+type MonitoringSpec struct {
+	// Webhook enables the metrics Service of cert-manager-webhook
+	// +optional
+	Webhook bool `json:"webhook,omitempty"`
+	// CAInjector enables the metrics Service of cert-manager-cainjector
+	// +optional
+	CAInjector bool `json:"caInjector,omitempty"`
+	// DisableServiceMonitors stops the creation of ServiceMonitors, which
+	// are otherwise created when the monitoring.coreos.com API is installed
+	// +optional
+	DisableServiceMonitors bool `json:"disableServiceMonitors,omitempty"`
+	// ServiceMonitorLabels are added to the ServiceMonitors, so that they
+	// are selected by the Prometheus instance
+	// +optional
+	ServiceMonitorLabels map[string]string `json:"serviceMonitorLabels,omitempty"`
+	// ScrapeInterval is the interval Prometheus scrapes the metrics at.
+	// Defaults to 60s
+	// +optional
+	ScrapeInterval string `json:"scrapeInterval,omitempty"`
+}
+
+// DefaultIssuersSpec describes the chain of default issuers
+type DefaultIssuersSpec struct {
+	// Enabled creates the chain. Disabling it removes the issuers and the CA
+	// Certificate, the CA secret is kept
+	Enabled bool `json:"enabled,omitempty"`
+	// Name is the name of the CA ClusterIssuer. The self-signed ClusterIssuer
+	// is named <name>-selfsigned, the CA Certificate and its secret <name>-ca.
+	// Defaults to ibm-cert-manager-default-issuer
+	// +optional
+	Name string `json:"name,omitempty"`
+	// KeyAlgorithm is the algorithm of the CA private key. Defaults to ECDSA
+	// +kubebuilder:validation:Enum=RSA;ECDSA
+	// +optional
+	KeyAlgorithm string `json:"keyAlgorithm,omitempty"`
+	// KeySize is the size of the CA private key. Defaults to 256 for ECDSA
+	// and 2048 for RSA
+	// +optional
+	KeySize int `json:"keySize,omitempty"`
+	// Duration is the lifetime of the CA certificate. Defaults to 43800h
+	// (5 years)
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// RenewBefore is how long before expiry the CA certificate is renewed.
+	// Defaults to 720h (30 days)
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+// ExpiryMonitoringSpec describes when Certificates and TLS secrets are reported
+// with Warning events and metrics
+type ExpiryMonitoringSpec struct {
+	// Disabled turns off the monitoring
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+	// WarningThreshold is how long before expiry a certificate is reported
+	// as expiring. Defaults to 720h (30 days)
+	// +optional
+	WarningThreshold *metav1.Duration `json:"warningThreshold,omitempty"`
+	// CriticalThreshold is how long before expiry a certificate is reported
+	// as critical. Defaults to 168h (7 days)
+	// +optional
+	CriticalThreshold *metav1.Duration `json:"criticalThreshold,omitempty"`
+	// RenewalGracePeriod is how long after its renewal time a Certificate is
+	// reported as overdue for renewal. Defaults to 1h
+	// +optional
+	RenewalGracePeriod *metav1.Duration `json:"renewalGracePeriod,omitempty"`
+	// FailedIssuanceAttempts is the number of failed issuance attempts after
+	// which a Certificate is reported as failing. Defaults to 3
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailedIssuanceAttempts *int32 `json:"failedIssuanceAttempts,omitempty"`
+}
+
+// ObservabilitySpec describes the telemetry configuration of the operands,
+// rendered into the standard OTEL_* environment variables of the operands
+// which read them. The operands of this release do not, only the address of
+// the Instana agent is injected
+type ObservabilitySpec struct {
+	// Provider is the telemetry backend. With instana, the address of the
+	// Instana agent is injected and used as the default exporter endpoint.
+	// Defaults to otel
+	// +kubebuilder:validation:Enum=otel;instana
+	// +optional
+	Provider string `json:"provider,omitempty"`
+	// Endpoint is the OTLP exporter endpoint, e.g.
+	// http://otel-collector.observability:4317. It may reference the agent
+	// host with $(OTEL_AGENT_HOST) when agentHostSource is set
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// Protocol is the OTLP exporter protocol
+	// +kubebuilder:validation:Enum=grpc;http/protobuf;http/json
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+	// SamplingRatio is the ratio of traces sampled, between 0 and 1.
+	// Defaults to the SDK default of sampling every trace
+	// +kubebuilder:validation:Pattern=`^(0(\.[0-9]+)?|1(\.0+)?)$`
+	// +optional
+	SamplingRatio string `json:"samplingRatio,omitempty"`
+	// ResourceAttributes are added to the telemetry of every operand
+	// +optional
+	ResourceAttributes map[string]string `json:"resourceAttributes,omitempty"`
+	// AgentHostSource exposes the address of a node local agent to the
+	// operands, taken from the IP of the node or of the pod. Defaults to
+	// HostIP for the instana provider
+	// +kubebuilder:validation:Enum=HostIP;PodIP
+	// +optional
+	AgentHostSource string `json:"agentHostSource,omitempty"`
+}
+
+// WebhookTLSSpec describes how the serving certificate of cert-manager-webhook
+// is issued
+type WebhookTLSSpec struct {
+	// Mode selects who issues the serving certificate. With Dynamic,
+	// cert-manager-webhook generates a CA into the cert-manager-webhook-ca
+	// secret and cert-manager-cainjector injects it into the webhook
+	// configurations. With Operator, the operator generates the CA and the
+	// serving certificate into the cert-manager-webhook-ca secret, injects
+	// the CA bundle into the webhook configurations and the conversion
+	// webhooks of the cert-manager CRDs, and rotates them ahead of expiry.
+	// Defaults to Dynamic
+	// +kubebuilder:validation:Enum=Dynamic;Operator
+	// +optional
+	Mode string `json:"mode,omitempty"`
+	// CADuration is the validity of the CA generated in Operator mode.
+	// Defaults to 43800h (5 years)
+	// +optional
+	CADuration *metav1.Duration `json:"caDuration,omitempty"`
+	// Duration is the validity of the serving certificate generated in
+	// Operator mode. Defaults to 8760h (1 year)
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// RenewBefore is how long before expiry the CA and the serving
+	// certificate are rotated in Operator mode. Defaults to 720h (30 days)
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
 }
 
 // CACertificate describes a CA Certfiicate's name and namespace
@@ -105,6 +441,133 @@ type CertManagerConfigStatus struct {
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors=true
 	// +operator-sdk:gen-csv:customresourcedefinitions.statusDescriptors.displayName="CertManagerConfig Status"
 	OverallStatus string `json:"certManagerConfigStatus"`
+
+	// EffectiveResources describes the resource requirements in effect for
+	// each operand, after applying the profile and any explicit overrides
+	// +optional
+	EffectiveResources *EffectiveResources `json:"effectiveResources,omitempty"`
+
+	// WebhookTLS reports the expiry of the CA and serving certificate of
+	// cert-manager-webhook when they are issued by the operator
+	// +optional
+	WebhookTLS *WebhookTLSStatus `json:"webhookTLS,omitempty"`
+
+	// DefaultIssuers reports the readiness of each link of the chain of
+	// default issuers
+	// +optional
+	DefaultIssuers *DefaultIssuersStatus `json:"defaultIssuers,omitempty"`
+
+	// CertificateExpiry summarizes the expiry of the Certificates and TLS
+	// secrets of the cluster
+	// +optional
+	CertificateExpiry *CertificateExpirySummary `json:"certificateExpiry,omitempty"`
+
+	// CompletedCleanupSteps lists the cleanup steps for resources of older
+	// operator releases which have completed, so that they are not run again
+	// +optional
+	CompletedCleanupSteps []string `json:"completedCleanupSteps,omitempty"`
+
+	// Conditions describe the state of the features of the operator
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// Condition types reported in the status
+const (
+	// ConditionNamespaceScoped is true when cert-manager-controller only
+	// watches the namespaces in spec.watchNamespaces. The message lists the
+	// features which are unavailable
+	ConditionNamespaceScoped = "NamespaceScoped"
+	// ConditionPermissionsMissing is true when the operator lacks permissions
+	// it needs to reconcile. The message lists the rules to grant
+	ConditionPermissionsMissing = "PermissionsMissing"
+	// ConditionWebhookCircuitOpen is true while the webhooks are relaxed
+	// because cert-manager-webhook has no ready endpoints
+	ConditionWebhookCircuitOpen = "WebhookCircuitOpen"
+	// ConditionWebhookFunctional is true when the API server calls
+	// cert-manager-webhook successfully. The message gives the reason of
+	// the failure otherwise
+	ConditionWebhookFunctional = "WebhookFunctional"
+	// ConditionDefaultIssuersReady is true when every link of the chain of
+	// default issuers is ready. The message names the first one which is not
+	ConditionDefaultIssuersReady = "DefaultIssuersReady"
+)
+
+// WebhookTLSStatus describes the certificates of cert-manager-webhook issued by
+// the operator
+type WebhookTLSStatus struct {
+	// CANotAfter is the expiry of the CA
+	CANotAfter metav1.Time `json:"caNotAfter,omitempty"`
+	// NotAfter is the expiry of the serving certificate
+	NotAfter metav1.Time `json:"notAfter,omitempty"`
+}
+
+// DefaultIssuersStatus describes the readiness of the default issuers
+type DefaultIssuersStatus struct {
+	SelfSignedIssuer DefaultIssuerStatus `json:"selfSignedIssuer"`
+	CACertificate    DefaultIssuerStatus `json:"caCertificate"`
+	CAIssuer         DefaultIssuerStatus `json:"caIssuer"`
+}
+
+// DefaultIssuerStatus describes the readiness of a ClusterIssuer or
+// Certificate of the chain of default issuers
+type DefaultIssuerStatus struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+	// Message is the message of the Ready condition of the resource
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// CertificateExpirySummary counts the Certificates and TLS secrets by state.
+// A certificate is counted in the most severe expiry state it is in
+type CertificateExpirySummary struct {
+	// LastScan is when the certificates were scanned for this summary. The
+	// summary is only updated when it changes
+	LastScan metav1.Time `json:"lastScan,omitempty"`
+	// Total is the number of certificates scanned
+	Total int32 `json:"total"`
+	// Expired is the number of expired certificates
+	Expired int32 `json:"expired"`
+	// Critical is the number of certificates expiring within the critical
+	// threshold
+	Critical int32 `json:"critical"`
+	// Warning is the number of certificates expiring within the warning
+	// threshold
+	Warning int32 `json:"warning"`
+	// RenewalOverdue is the number of Certificates past their renewal time
+	RenewalOverdue int32 `json:"renewalOverdue"`
+	// IssuanceFailing is the number of Certificates whose issuance keeps
+	// failing
+	IssuanceFailing int32 `json:"issuanceFailing"`
+	// NextExpiry is the certificate expiring first
+	// +optional
+	NextExpiry *ExpiringCertificate `json:"nextExpiry,omitempty"`
+}
+
+// ExpiringCertificate identifies a Certificate or TLS secret and its expiry
+type ExpiringCertificate struct {
+	// Kind is Certificate or Secret
+	Kind      string      `json:"kind"`
+	Namespace string      `json:"namespace"`
+	Name      string      `json:"name"`
+	NotAfter  metav1.Time `json:"notAfter"`
+}
+
+// EffectiveResources describes the resource requirements deployed for each
+// operand
+type EffectiveResources struct {
+	// Profile is the resource profile the requirements are based on, empty
+	// when the operator defaults are used
+	Profile string `json:"profile,omitempty"`
+	// CertManagerController describes the resources of cert-manager-controller
+	CertManagerController corev1.ResourceRequirements `json:"certManagerController,omitempty"`
+	// CertManagerWebhook describes the resources of cert-manager-webhook
+	CertManagerWebhook corev1.ResourceRequirements `json:"certManagerWebhook,omitempty"`
+	// CertManagerCAInjector describes the resources of cert-manager-cainjector
+	CertManagerCAInjector corev1.ResourceRequirements `json:"certManagerCAInjector,omitempty"`
 }
 
 //+kubebuilder:object:root=true
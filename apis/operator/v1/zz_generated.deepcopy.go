@@ -21,9 +21,56 @@ limitations under the License.
 package v1
 
 import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoscalingSpec) DeepCopyInto(out *AutoscalingSpec) {
+	*out = *in
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TargetCPUUtilizationPercentage != nil {
+		in, out := &in.TargetCPUUtilizationPercentage, &out.TargetCPUUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MinAllowed != nil {
+		in, out := &in.MinAllowed, &out.MinAllowed
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.MaxAllowed != nil {
+		in, out := &in.MaxAllowed, &out.MaxAllowed
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoscalingSpec.
+func (in *AutoscalingSpec) DeepCopy() *AutoscalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoscalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CACertificate) DeepCopyInto(out *CACertificate) {
 	*out = *in
@@ -45,7 +92,7 @@ func (in *CertManagerConfig) DeepCopyInto(out *CertManagerConfig) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerConfig.
@@ -110,6 +157,11 @@ func (in *CertManagerConfigSpec) DeepCopyInto(out *CertManagerConfigSpec) {
 	in.CertManagerWebhook.DeepCopyInto(&out.CertManagerWebhook)
 	in.CertManagerCAInjector.DeepCopyInto(&out.CertManagerCAInjector)
 	in.ConfigMapWatcher.DeepCopyInto(&out.ConfigMapWatcher)
+	if in.WebhookTLS != nil {
+		in, out := &in.WebhookTLS, &out.WebhookTLS
+		*out = new(WebhookTLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.EnableCertRefresh != nil {
 		in, out := &in.EnableCertRefresh, &out.EnableCertRefresh
 		*out = new(bool)
@@ -120,6 +172,36 @@ func (in *CertManagerConfigSpec) DeepCopyInto(out *CertManagerConfigSpec) {
 		*out = make([]CACertificate, len(*in))
 		copy(*out, *in)
 	}
+	if in.WatchNamespaces != nil {
+		in, out := &in.WatchNamespaces, &out.WatchNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EnableAggregatedClusterRoles != nil {
+		in, out := &in.EnableAggregatedClusterRoles, &out.EnableAggregatedClusterRoles
+		*out = new(bool)
+		**out = **in
+	}
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(MonitoringSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DefaultIssuers != nil {
+		in, out := &in.DefaultIssuers, &out.DefaultIssuers
+		*out = new(DefaultIssuersSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExpiryMonitoring != nil {
+		in, out := &in.ExpiryMonitoring, &out.ExpiryMonitoring
+		*out = new(ExpiryMonitoringSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Labels != nil {
 		in, out := &in.Labels, &out.Labels
 		*out = make(map[string]string, len(*in))
@@ -128,6 +210,11 @@ func (in *CertManagerConfigSpec) DeepCopyInto(out *CertManagerConfigSpec) {
 		}
 	}
 	out.License = in.License
+	if in.Observability != nil {
+		in, out := &in.Observability, &out.Observability
+		*out = new(ObservabilitySpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerConfigSpec.
@@ -143,6 +230,38 @@ func (in *CertManagerConfigSpec) DeepCopy() *CertManagerConfigSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertManagerConfigStatus) DeepCopyInto(out *CertManagerConfigStatus) {
 	*out = *in
+	if in.EffectiveResources != nil {
+		in, out := &in.EffectiveResources, &out.EffectiveResources
+		*out = new(EffectiveResources)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WebhookTLS != nil {
+		in, out := &in.WebhookTLS, &out.WebhookTLS
+		*out = new(WebhookTLSStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DefaultIssuers != nil {
+		in, out := &in.DefaultIssuers, &out.DefaultIssuers
+		*out = new(DefaultIssuersStatus)
+		**out = **in
+	}
+	if in.CertificateExpiry != nil {
+		in, out := &in.CertificateExpiry, &out.CertificateExpiry
+		*out = new(CertificateExpirySummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CompletedCleanupSteps != nil {
+		in, out := &in.CompletedCleanupSteps, &out.CompletedCleanupSteps
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerConfigStatus.
@@ -159,6 +278,31 @@ func (in *CertManagerConfigStatus) DeepCopy() *CertManagerConfigStatus {
 func (in *CertManagerContainerSpec) DeepCopyInto(out *CertManagerContainerSpec) {
 	*out = *in
 	in.Resources.DeepCopyInto(&out.Resources)
+	if in.LogLevel != nil {
+		in, out := &in.LogLevel, &out.LogLevel
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(appsv1.DeploymentStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(AutoscalingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Admission != nil {
+		in, out := &in.Admission, &out.Admission
+		*out = new(WebhookAdmissionSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerContainerSpec.
@@ -171,6 +315,272 @@ func (in *CertManagerContainerSpec) DeepCopy() *CertManagerContainerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateExpirySummary) DeepCopyInto(out *CertificateExpirySummary) {
+	*out = *in
+	in.LastScan.DeepCopyInto(&out.LastScan)
+	if in.NextExpiry != nil {
+		in, out := &in.NextExpiry, &out.NextExpiry
+		*out = new(ExpiringCertificate)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateExpirySummary.
+func (in *CertificateExpirySummary) DeepCopy() *CertificateExpirySummary {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateExpirySummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatePolicy) DeepCopyInto(out *CertificatePolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatePolicy.
+func (in *CertificatePolicy) DeepCopy() *CertificatePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificatePolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatePolicyIssuer) DeepCopyInto(out *CertificatePolicyIssuer) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatePolicyIssuer.
+func (in *CertificatePolicyIssuer) DeepCopy() *CertificatePolicyIssuer {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatePolicyIssuer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatePolicyList) DeepCopyInto(out *CertificatePolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CertificatePolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatePolicyList.
+func (in *CertificatePolicyList) DeepCopy() *CertificatePolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatePolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CertificatePolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatePolicySpec) DeepCopyInto(out *CertificatePolicySpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MinRSAKeySize != nil {
+		in, out := &in.MinRSAKeySize, &out.MinRSAKeySize
+		*out = new(int)
+		**out = **in
+	}
+	if in.AllowedECDSAKeySizes != nil {
+		in, out := &in.AllowedECDSAKeySizes, &out.AllowedECDSAKeySizes
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedKeyAlgorithms != nil {
+		in, out := &in.AllowedKeyAlgorithms, &out.AllowedKeyAlgorithms
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxDuration != nil {
+		in, out := &in.MaxDuration, &out.MaxDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.AllowedIssuers != nil {
+		in, out := &in.AllowedIssuers, &out.AllowedIssuers
+		*out = make([]CertificatePolicyIssuer, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatePolicySpec.
+func (in *CertificatePolicySpec) DeepCopy() *CertificatePolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatePolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultIssuerStatus) DeepCopyInto(out *DefaultIssuerStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultIssuerStatus.
+func (in *DefaultIssuerStatus) DeepCopy() *DefaultIssuerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultIssuerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultIssuersSpec) DeepCopyInto(out *DefaultIssuersSpec) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultIssuersSpec.
+func (in *DefaultIssuersSpec) DeepCopy() *DefaultIssuersSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultIssuersSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DefaultIssuersStatus) DeepCopyInto(out *DefaultIssuersStatus) {
+	*out = *in
+	out.SelfSignedIssuer = in.SelfSignedIssuer
+	out.CACertificate = in.CACertificate
+	out.CAIssuer = in.CAIssuer
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultIssuersStatus.
+func (in *DefaultIssuersStatus) DeepCopy() *DefaultIssuersStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DefaultIssuersStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EffectiveResources) DeepCopyInto(out *EffectiveResources) {
+	*out = *in
+	in.CertManagerController.DeepCopyInto(&out.CertManagerController)
+	in.CertManagerWebhook.DeepCopyInto(&out.CertManagerWebhook)
+	in.CertManagerCAInjector.DeepCopyInto(&out.CertManagerCAInjector)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EffectiveResources.
+func (in *EffectiveResources) DeepCopy() *EffectiveResources {
+	if in == nil {
+		return nil
+	}
+	out := new(EffectiveResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpiringCertificate) DeepCopyInto(out *ExpiringCertificate) {
+	*out = *in
+	in.NotAfter.DeepCopyInto(&out.NotAfter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpiringCertificate.
+func (in *ExpiringCertificate) DeepCopy() *ExpiringCertificate {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpiringCertificate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpiryMonitoringSpec) DeepCopyInto(out *ExpiryMonitoringSpec) {
+	*out = *in
+	if in.WarningThreshold != nil {
+		in, out := &in.WarningThreshold, &out.WarningThreshold
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.CriticalThreshold != nil {
+		in, out := &in.CriticalThreshold, &out.CriticalThreshold
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewalGracePeriod != nil {
+		in, out := &in.RenewalGracePeriod, &out.RenewalGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.FailedIssuanceAttempts != nil {
+		in, out := &in.FailedIssuanceAttempts, &out.FailedIssuanceAttempts
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpiryMonitoringSpec.
+func (in *ExpiryMonitoringSpec) DeepCopy() *ExpiryMonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpiryMonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LicenseAcceptance) DeepCopyInto(out *LicenseAcceptance) {
 	*out = *in
@@ -185,3 +595,174 @@ func (in *LicenseAcceptance) DeepCopy() *LicenseAcceptance {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+	*out = *in
+	if in.ServiceMonitorLabels != nil {
+		in, out := &in.ServiceMonitorLabels, &out.ServiceMonitorLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
+func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicySpec) DeepCopyInto(out *NetworkPolicySpec) {
+	*out = *in
+	if in.APIServerCIDRs != nil {
+		in, out := &in.APIServerCIDRs, &out.APIServerCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EgressCIDRs != nil {
+		in, out := &in.EgressCIDRs, &out.EgressCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicySpec.
+func (in *NetworkPolicySpec) DeepCopy() *NetworkPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservabilitySpec) DeepCopyInto(out *ObservabilitySpec) {
+	*out = *in
+	if in.ResourceAttributes != nil {
+		in, out := &in.ResourceAttributes, &out.ResourceAttributes
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservabilitySpec.
+func (in *ObservabilitySpec) DeepCopy() *ObservabilitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ObservabilitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookAdmissionSpec) DeepCopyInto(out *WebhookAdmissionSpec) {
+	*out = *in
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObjectSelector != nil {
+		in, out := &in.ObjectSelector, &out.ObjectSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CircuitBreaker != nil {
+		in, out := &in.CircuitBreaker, &out.CircuitBreaker
+		*out = new(WebhookCircuitBreakerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookAdmissionSpec.
+func (in *WebhookAdmissionSpec) DeepCopy() *WebhookAdmissionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookAdmissionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookCircuitBreakerSpec) DeepCopyInto(out *WebhookCircuitBreakerSpec) {
+	*out = *in
+	if in.OutageDuration != nil {
+		in, out := &in.OutageDuration, &out.OutageDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookCircuitBreakerSpec.
+func (in *WebhookCircuitBreakerSpec) DeepCopy() *WebhookCircuitBreakerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookCircuitBreakerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTLSSpec) DeepCopyInto(out *WebhookTLSSpec) {
+	*out = *in
+	if in.CADuration != nil {
+		in, out := &in.CADuration, &out.CADuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTLSSpec.
+func (in *WebhookTLSSpec) DeepCopy() *WebhookTLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTLSSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookTLSStatus) DeepCopyInto(out *WebhookTLSStatus) {
+	*out = *in
+	in.CANotAfter.DeepCopyInto(&out.CANotAfter)
+	in.NotAfter.DeepCopyInto(&out.NotAfter)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookTLSStatus.
+func (in *WebhookTLSStatus) DeepCopy() *WebhookTLSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookTLSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
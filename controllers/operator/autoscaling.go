@@ -0,0 +1,233 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+// autoscaling reconciles the HorizontalPodAutoscaler of cert-manager-webhook
+// and the VerticalPodAutoscalers of cert-manager-controller and
+// cert-manager-cainjector
+func autoscaling(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, reader client.Reader, kubeclient kubernetes.Interface, ns string) error {
+	if err := webhookHPA(instance, scheme, client, ns); err != nil {
+		return err
+	}
+	if !vpaInstalled(kubeclient) {
+		if autoscalingEnabled(instance.Spec.CertManagerController) || autoscalingEnabled(instance.Spec.CertManagerCAInjector) {
+			logd.Info("Autoscaling is enabled but the VerticalPodAutoscaler API is not installed, skipping")
+		}
+		return nil
+	}
+	if err := vpa(instance, scheme, client, reader, res.CertManagerControllerName, true, ns); err != nil {
+		return err
+	}
+	if err := vpa(instance, scheme, client, reader, res.CertManagerCainjectorName, instance.Spec.Webhook, ns); err != nil {
+		return err
+	}
+	return nil
+}
+
+func autoscalingEnabled(spec operatorv1.CertManagerContainerSpec) bool {
+	return spec.Autoscaling != nil && spec.Autoscaling.Enabled
+}
+
+// vpaInstalled returns true if the VerticalPodAutoscaler API is served by the
+// cluster
+func vpaInstalled(kubeclient kubernetes.Interface) bool {
+	return kindServed(kubeclient, res.VPAGroupVersionKind)
+}
+
+// preserveAutoscaledFields copies the replicas of cert-manager-webhook from
+// the existing deployment while its HorizontalPodAutoscaler is enabled, so
+// that the deploy logic does not revert the scaling. The
+// VerticalPodAutoscalers of the other operands change the resources of the
+// pods at admission and never write the deployments, so their resources are
+// still reconciled from the CR
+func preserveAutoscaledFields(instance *operatorv1.CertManagerConfig, deployment *appsv1.Deployment, existing appsv1.Deployment) {
+	if deployment.Name != res.CertManagerWebhookName || !autoscalingEnabled(componentSpec(instance, deployment.Name)) {
+		return
+	}
+	deployment.Spec.Replicas = existing.Spec.Replicas
+}
+
+func webhookHPA(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, ns string) error {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	err := client.Get(context.Background(), types.NamespacedName{Name: res.CertManagerWebhookName, Namespace: ns}, hpa)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	spec := instance.Spec.CertManagerWebhook
+	if !instance.Spec.Webhook || !autoscalingEnabled(spec) {
+		if found {
+			logd.Info("Removing HorizontalPodAutoscaler " + res.CertManagerWebhookName)
+			if err := client.Delete(context.Background(), hpa); err != nil && !apiErrors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	desired := res.WebhookHPA.DeepCopy()
+	desired.Namespace = ns
+	if spec.Autoscaling.MinReplicas != nil {
+		desired.Spec.MinReplicas = spec.Autoscaling.MinReplicas
+	}
+	if spec.Autoscaling.MaxReplicas != nil {
+		desired.Spec.MaxReplicas = *spec.Autoscaling.MaxReplicas
+	}
+	if desired.Spec.MinReplicas != nil && *desired.Spec.MinReplicas > desired.Spec.MaxReplicas {
+		return fmt.Errorf("invalid autoscaling of %s: minReplicas %d is greater than maxReplicas %d",
+			res.CertManagerWebhookName, *desired.Spec.MinReplicas, desired.Spec.MaxReplicas)
+	}
+	if spec.Autoscaling.TargetCPUUtilizationPercentage != nil {
+		desired.Spec.Metrics[0].Resource.Target.AverageUtilization = spec.Autoscaling.TargetCPUUtilizationPercentage
+	}
+
+	if !found {
+		if err := controllerutil.SetControllerReference(instance, desired, scheme); err != nil {
+			logd.Error(err, "Error setting controller reference on horizontal pod autoscaler")
+		}
+		logd.Info("Creating HorizontalPodAutoscaler " + res.CertManagerWebhookName)
+		return client.Create(context.Background(), desired)
+	}
+
+	originalHPA := hpa.DeepCopy()
+	hpa.Labels = desired.Labels
+	hpa.Spec.ScaleTargetRef = desired.Spec.ScaleTargetRef
+	hpa.Spec.MinReplicas = desired.Spec.MinReplicas
+	hpa.Spec.MaxReplicas = desired.Spec.MaxReplicas
+	hpa.Spec.Metrics = desired.Spec.Metrics
+	if !equality.Semantic.DeepEqual(originalHPA, hpa) {
+		logd.Info("Updating HorizontalPodAutoscaler " + res.CertManagerWebhookName)
+		recordDriftCorrection("HorizontalPodAutoscaler")
+		return client.Update(context.Background(), hpa)
+	}
+	return nil
+}
+
+// vpa reconciles the VerticalPodAutoscaler of the operand with the given name.
+// The VerticalPodAutoscaler API is not part of the scheme, so the object is
+// handled as unstructured and read from the API server directly
+func vpa(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, reader client.Reader, name string, deployed bool, ns string) error {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(res.VPAGroupVersionKind)
+	err := reader.Get(context.Background(), types.NamespacedName{Name: name, Namespace: ns}, existing)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	spec := componentSpec(instance, name)
+	if !deployed || !autoscalingEnabled(spec) {
+		if found {
+			logd.Info("Removing VerticalPodAutoscaler " + name)
+			if err := client.Delete(context.Background(), existing); err != nil && !apiErrors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	updateMode := res.DefaultVPAUpdateMode
+	if spec.Autoscaling.UpdateMode != "" {
+		updateMode = spec.Autoscaling.UpdateMode
+	}
+	containerPolicy := map[string]interface{}{
+		"containerName": name,
+	}
+	if len(spec.Autoscaling.MinAllowed) > 0 {
+		containerPolicy["minAllowed"] = resourceListToMap(spec.Autoscaling.MinAllowed)
+	}
+	if len(spec.Autoscaling.MaxAllowed) > 0 {
+		containerPolicy["maxAllowed"] = resourceListToMap(spec.Autoscaling.MaxAllowed)
+	}
+	desiredSpec := map[string]interface{}{
+		"targetRef": map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"name":       name,
+		},
+		"updatePolicy": map[string]interface{}{
+			"updateMode": updateMode,
+		},
+		"resourcePolicy": map[string]interface{}{
+			"containerPolicies": []interface{}{containerPolicy},
+		},
+	}
+
+	if !found {
+		desired := &unstructured.Unstructured{}
+		desired.SetGroupVersionKind(res.VPAGroupVersionKind)
+		desired.SetName(name)
+		desired.SetNamespace(ns)
+		desired.SetLabels(deployLabels(name))
+		desired.Object["spec"] = desiredSpec
+		if err := controllerutil.SetControllerReference(instance, desired, scheme); err != nil {
+			logd.Error(err, "Error setting controller reference on vertical pod autoscaler")
+		}
+		logd.Info("Creating VerticalPodAutoscaler " + name)
+		return client.Create(context.Background(), desired)
+	}
+
+	if !equality.Semantic.DeepEqual(existing.Object["spec"], desiredSpec) {
+		existing.Object["spec"] = desiredSpec
+		logd.Info("Updating VerticalPodAutoscaler " + name)
+		recordDriftCorrection("VerticalPodAutoscaler")
+		return client.Update(context.Background(), existing)
+	}
+	return nil
+}
+
+// deployLabels returns the labels of the operand with the given name
+func deployLabels(name string) map[string]string {
+	switch name {
+	case res.CertManagerControllerName:
+		return res.ControllerLabelMap
+	case res.CertManagerCainjectorName:
+		return res.CainjectorLabelMap
+	case res.CertManagerWebhookName:
+		return res.WebhookLabelMap
+	}
+	return nil
+}
+
+func resourceListToMap(list corev1.ResourceList) map[string]interface{} {
+	result := make(map[string]interface{}, len(list))
+	for name, quantity := range list {
+		result[string(name)] = quantity.String()
+	}
+	return result
+}
@@ -0,0 +1,255 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	certmanagerv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/cert-manager/v1"
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+)
+
+// defaults of cert-manager for the fields of a Certificate checked by the
+// policies
+const (
+	defaultCertificateDuration = 90 * 24 * time.Hour
+	defaultRSAKeySize          = 2048
+	defaultECDSAKeySize        = 256
+)
+
+// CertificatePolicyValidator is the validating webhook evaluating the
+// CertificatePolicies when Certificates are created or updated. Certificates
+// violating a policy in Enforce mode are rejected, violations of policies in
+// Audit mode are returned as warnings
+type CertificatePolicyValidator struct {
+	Client client.Client
+
+	decoder *admission.Decoder
+}
+
+// NewCertificatePolicyValidator returns a validator decoding Certificates
+// with the scheme
+func NewCertificatePolicyValidator(c client.Client, scheme *runtime.Scheme) *CertificatePolicyValidator {
+	return &CertificatePolicyValidator{Client: c, decoder: admission.NewDecoder(scheme)}
+}
+
+// namespaceMetadata returns an empty Namespace metadata object. Only the
+// metadata of the namespaces is cached, for the namespace selectors of the
+// policies
+func namespaceMetadata() *metav1.PartialObjectMetadata {
+	namespace := &metav1.PartialObjectMetadata{}
+	namespace.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+	return namespace
+}
+
+// Handle evaluates the policies applying to the namespace of the Certificate
+func (v *CertificatePolicyValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cert := &certmanagerv1.Certificate{}
+	if err := v.decoder.DecodeRaw(req.Object, cert); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if req.Operation == admissionv1.Update {
+		old := &certmanagerv1.Certificate{}
+		if err := v.decoder.DecodeRaw(req.OldObject, old); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		// existing Certificates are only checked when their spec changes, so
+		// that their metadata can still be updated
+		if equality.Semantic.DeepEqual(old.Spec, cert.Spec) {
+			return admission.Allowed("")
+		}
+	}
+
+	// the self-test Certificates of cert-manager-webhook are created with
+	// dry-run only, and must reach cert-manager-webhook whatever the policies
+	if isSelfTestRequest(req, cert) {
+		return admission.Allowed("")
+	}
+
+	policies := &operatorv1.CertificatePolicyList{}
+	if err := v.Client.List(ctx, policies); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	var namespaceLabels map[string]string
+	for i := range policies.Items {
+		if policies.Items[i].Spec.NamespaceSelector != nil {
+			namespace := namespaceMetadata()
+			if err := v.Client.Get(ctx, client.ObjectKey{Name: req.Namespace}, namespace); err != nil {
+				return admission.Errored(http.StatusInternalServerError, err)
+			}
+			namespaceLabels = namespace.Labels
+			break
+		}
+	}
+
+	denied, warnings := evaluateCertificatePolicies(policies.Items, namespaceLabels, cert)
+	if len(warnings) > 0 {
+		logd.Info("Certificate admitted in violation of CertificatePolicies in Audit mode",
+			"certificate", req.Namespace+"/"+cert.Name, "violations", warnings)
+	}
+	if len(denied) > 0 {
+		return admission.Denied(strings.Join(denied, "; ")).WithWarnings(warnings...)
+	}
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// isSelfTestRequest returns true for the dry-run requests of the webhook
+// self-test, which are never persisted
+func isSelfTestRequest(req admission.Request, cert *certmanagerv1.Certificate) bool {
+	return req.DryRun != nil && *req.DryRun && cert.Name == selfTestName
+}
+
+// evaluateCertificatePolicies returns the violations of the policies applying
+// to a Certificate in a namespace with the given labels. The violations of
+// the policies in Enforce mode are denied, the ones in Audit mode are
+// warnings
+func evaluateCertificatePolicies(policies []operatorv1.CertificatePolicy, namespaceLabels map[string]string, cert *certmanagerv1.Certificate) (denied, warnings []string) {
+	for i := range policies {
+		policy := &policies[i]
+		if policy.Spec.NamespaceSelector != nil {
+			selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+			if err != nil {
+				logd.Error(err, "Invalid namespace selector of CertificatePolicy", "policy", policy.Name)
+				continue
+			}
+			if !selector.Matches(labels.Set(namespaceLabels)) {
+				continue
+			}
+		}
+
+		violations := certificatePolicyViolations(&policy.Spec, cert)
+		if len(violations) == 0 {
+			continue
+		}
+		mode := policy.Spec.Mode
+		if mode == "" {
+			mode = operatorv1.CertificatePolicyEnforce
+		}
+		certificatePolicyViolationsTotal.WithLabelValues(policy.Name, mode).Inc()
+		for _, violation := range violations {
+			message := fmt.Sprintf("CertificatePolicy %s: %s", policy.Name, violation)
+			if mode == operatorv1.CertificatePolicyAudit {
+				warnings = append(warnings, message)
+			} else {
+				denied = append(denied, message)
+			}
+		}
+	}
+	return denied, warnings
+}
+
+// certificatePolicyViolations returns the rules of the policy the Certificate
+// violates, the fields not set in the Certificate are checked with the
+// defaults of cert-manager
+func certificatePolicyViolations(spec *operatorv1.CertificatePolicySpec, cert *certmanagerv1.Certificate) []string {
+	var violations []string
+
+	algorithm := certmanagerv1.RSAKeyAlgorithm
+	size := 0
+	if cert.Spec.PrivateKey != nil {
+		if cert.Spec.PrivateKey.Algorithm != "" {
+			algorithm = cert.Spec.PrivateKey.Algorithm
+		}
+		size = cert.Spec.PrivateKey.Size
+	}
+	if size == 0 {
+		switch algorithm {
+		case certmanagerv1.RSAKeyAlgorithm:
+			size = defaultRSAKeySize
+		case certmanagerv1.ECDSAKeyAlgorithm:
+			size = defaultECDSAKeySize
+		}
+	}
+	if len(spec.AllowedKeyAlgorithms) > 0 && !containsString(spec.AllowedKeyAlgorithms, string(algorithm)) {
+		violations = append(violations, fmt.Sprintf("private key algorithm %s is not allowed, allowed algorithms are %s",
+			algorithm, strings.Join(spec.AllowedKeyAlgorithms, ", ")))
+	}
+	if algorithm == certmanagerv1.RSAKeyAlgorithm && spec.MinRSAKeySize != nil && size < *spec.MinRSAKeySize {
+		violations = append(violations, fmt.Sprintf("RSA private key size %d is smaller than %d", size, *spec.MinRSAKeySize))
+	}
+	if algorithm == certmanagerv1.ECDSAKeyAlgorithm && len(spec.AllowedECDSAKeySizes) > 0 && !containsInt(spec.AllowedECDSAKeySizes, size) {
+		violations = append(violations, fmt.Sprintf("ECDSA private key size %d is not allowed, allowed sizes are %v", size, spec.AllowedECDSAKeySizes))
+	}
+
+	if spec.MaxDuration != nil {
+		duration := defaultCertificateDuration
+		if cert.Spec.Duration != nil {
+			duration = cert.Spec.Duration.Duration
+		}
+		if duration > spec.MaxDuration.Duration {
+			violations = append(violations, fmt.Sprintf("duration %s is longer than %s", duration, spec.MaxDuration.Duration))
+		}
+	}
+
+	if len(spec.AllowedIssuers) > 0 && !issuerAllowed(spec.AllowedIssuers, cert) {
+		violations = append(violations, fmt.Sprintf("issuer %s is not allowed", issuerName(cert.Spec.IssuerRef.Kind, cert.Spec.IssuerRef.Name)))
+	}
+
+	if spec.ForbidWildcardDNSNames {
+		for _, name := range append([]string{cert.Spec.CommonName}, cert.Spec.DNSNames...) {
+			if strings.HasPrefix(name, "*") {
+				violations = append(violations, fmt.Sprintf("wildcard DNS name %s is not allowed", name))
+			}
+		}
+	}
+	return violations
+}
+
+// issuerAllowed returns true if the issuer of the Certificate is one of the
+// allowed cert-manager issuers
+func issuerAllowed(allowed []operatorv1.CertificatePolicyIssuer, cert *certmanagerv1.Certificate) bool {
+	ref := cert.Spec.IssuerRef
+	if ref.Group != "" && ref.Group != certmanagerv1.GroupVersion.Group {
+		return false
+	}
+	kind := ref.Kind
+	if kind == "" {
+		kind = "Issuer"
+	}
+	for _, issuer := range allowed {
+		allowedKind := issuer.Kind
+		if allowedKind == "" {
+			allowedKind = "Issuer"
+		}
+		if allowedKind == kind && (issuer.Name == "*" || issuer.Name == ref.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
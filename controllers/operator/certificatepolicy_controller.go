@@ -0,0 +1,403 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"sync"
+	"time"
+
+	admRegv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+const (
+	// policyWebhookRecheck is how often the serving certificate of the policy
+	// webhook is checked for renewal
+	policyWebhookRecheck = 24 * time.Hour
+
+	// policyWebhookCertReload is how often the webhook server reloads its
+	// serving certificate from the serving secret
+	policyWebhookCertReload = 10 * time.Minute
+
+	policyWebhookTimeout int32 = 10
+)
+
+// CertificatePolicyReconciler sets up the serving of the CertificatePolicy
+// webhook by the operator: the serving secret and service in the namespace of
+// the operator, and the ValidatingWebhookConfiguration, which only exists
+// while there are CertificatePolicies and a CertManagerConfig. Its failure
+// policy is Fail if one of them is in Enforce mode, so that Certificates can
+// not bypass the policies while the operator is unavailable: the operator must
+// keep running while such policies exist. The configuration is owned by the
+// CertManagerConfig, so that it is garbage collected with it when the
+// operator is uninstalled, and does not apply to the namespace of the operator
+type CertificatePolicyReconciler struct {
+	Client client.Client
+	Reader client.Reader
+	Scheme *runtime.Scheme
+	NS     string
+}
+
+// Reconcile is called for any CertificatePolicy, it reconciles the webhook
+// for all of them
+func (r *CertificatePolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	policies := &operatorv1.CertificatePolicyList{}
+	if err := r.Client.List(ctx, policies); err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(policies.Items) == 0 {
+		return ctrl.Result{}, r.removePolicyWebhook(ctx)
+	}
+	instances := &operatorv1.CertManagerConfigList{}
+	if err := r.Client.List(ctx, instances); err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(instances.Items) == 0 {
+		logd.Info("No CertManagerConfig found, CertificatePolicies are not enforced")
+		return ctrl.Result{}, r.removePolicyWebhook(ctx)
+	}
+
+	caBundle, err := r.policyWebhookTLS(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.policyWebhookService(ctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	failurePolicy := admRegv1.Ignore
+	for _, policy := range policies.Items {
+		if policy.Spec.Mode != operatorv1.CertificatePolicyAudit {
+			failurePolicy = admRegv1.Fail
+		}
+	}
+	if err := r.policyWebhookConfiguration(ctx, instances.Items, caBundle, failurePolicy); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: policyWebhookRecheck}, nil
+}
+
+// policyWebhookTLS issues and renews the CA and serving certificate of the
+// policy webhook, and returns the CA bundle to inject
+func (r *CertificatePolicyReconciler) policyWebhookTLS(ctx context.Context) ([]byte, error) {
+	secret := &corev1.Secret{}
+	// read from API server directly, secrets are not cached
+	err := r.Reader.Get(ctx, types.NamespacedName{Name: res.PolicyWebhookName, Namespace: r.NS}, secret)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return nil, err
+	}
+	exists := err == nil
+
+	certs := &webhookCertificates{}
+	if exists {
+		// an unreadable secret is reissued from scratch
+		if certs, err = parseWebhookCertificates(secret); err != nil {
+			logd.Error(err, "Reissuing invalid policy webhook serving secret "+res.PolicyWebhookName)
+			certs = &webhookCertificates{}
+		}
+	}
+
+	now := time.Now()
+	rotated := false
+	if certs.ca == nil || now.Add(defaultWebhookRenewBefore).After(certs.ca.NotAfter) {
+		ca, caKey, err := newWebhookCA(defaultWebhookCADuration, res.PolicyWebhookName+"-ca")
+		if err != nil {
+			return nil, err
+		}
+		bundle := []*x509.Certificate{ca}
+		if certs.ca != nil && now.Before(certs.ca.NotAfter) {
+			bundle = append(bundle, certs.ca)
+		}
+		certs = &webhookCertificates{ca: ca, caKey: caKey, bundle: bundle}
+		rotated = true
+	}
+	dnsNames := policyWebhookDNSNames(r.NS)
+	if certs.serving == nil || now.Add(defaultWebhookRenewBefore).After(certs.serving.NotAfter) ||
+		certs.serving.CheckSignatureFrom(certs.ca) != nil || !equality.Semantic.DeepEqual(certs.serving.DNSNames, dnsNames) {
+		rotated = true
+	}
+	if !rotated {
+		return secret.Data[corev1.ServiceAccountRootCAKey], nil
+	}
+
+	data, err := issueWebhookCertificates(certs, defaultWebhookTLSDuration, dnsNames)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		logd.Info("Creating policy webhook serving secret " + res.PolicyWebhookName)
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      res.PolicyWebhookName,
+				Namespace: r.NS,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "ibm-cert-manager-operator"},
+			},
+			Type: corev1.SecretTypeTLS,
+			Data: data,
+		}
+		if err := r.Client.Create(ctx, secret); err != nil {
+			return nil, err
+		}
+	} else {
+		logd.Info("Rotating policy webhook serving secret " + res.PolicyWebhookName)
+		secret.Data = data
+		if err := r.Client.Update(ctx, secret); err != nil {
+			return nil, err
+		}
+	}
+	return data[corev1.ServiceAccountRootCAKey], nil
+}
+
+// policyWebhookDNSNames are the DNS names of the service of the policy webhook
+func policyWebhookDNSNames(ns string) []string {
+	return []string{res.PolicyWebhookName, res.PolicyWebhookName + "." + ns, res.PolicyWebhookName + "." + ns + ".svc"}
+}
+
+// policyWebhookService reconciles the service of the policy webhook, selecting
+// the operator pods
+func (r *CertificatePolicyReconciler) policyWebhookService(ctx context.Context) error {
+	desired := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      res.PolicyWebhookName,
+			Namespace: r.NS,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "ibm-cert-manager-operator"},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: res.OperatorPodLabels,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "https",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       443,
+					TargetPort: intstr.FromInt32(res.PolicyWebhookPort),
+				},
+			},
+		},
+	}
+	existing := &corev1.Service{}
+	err := r.Reader.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
+	if apiErrors.IsNotFound(err) {
+		logd.Info("Creating policy webhook service " + desired.Name)
+		return r.Client.Create(ctx, desired)
+	} else if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(existing.Spec.Selector, desired.Spec.Selector) &&
+		equality.Semantic.DeepEqual(existing.Spec.Ports, desired.Spec.Ports) {
+		return nil
+	}
+	recordDriftCorrection("Service")
+	logd.Info("Updating policy webhook service " + desired.Name)
+	existing.Spec.Selector = desired.Spec.Selector
+	existing.Spec.Ports = desired.Spec.Ports
+	return r.Client.Update(ctx, existing)
+}
+
+// policyWebhookConfiguration reconciles the ValidatingWebhookConfiguration of
+// the policy webhook, owned by the CertManagerConfigs
+func (r *CertificatePolicyReconciler) policyWebhookConfiguration(ctx context.Context, instances []operatorv1.CertManagerConfig, caBundle []byte, failurePolicy admRegv1.FailurePolicyType) error {
+	path := res.PolicyWebhookPath
+	port := int32(443)
+	scope := admRegv1.AllScopes
+	sideEffects := admRegv1.SideEffectClassNone
+	matchPolicy := admRegv1.Equivalent
+	timeout := policyWebhookTimeout
+	desired := &admRegv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   res.PolicyWebhookName,
+			Labels: map[string]string{"app.kubernetes.io/managed-by": "ibm-cert-manager-operator"},
+		},
+		Webhooks: []admRegv1.ValidatingWebhook{
+			{
+				Name: "certificatepolicy.operator.ibm.com",
+				ClientConfig: admRegv1.WebhookClientConfig{
+					Service: &admRegv1.ServiceReference{
+						Namespace: r.NS,
+						Name:      res.PolicyWebhookName,
+						Path:      &path,
+						Port:      &port,
+					},
+					CABundle: caBundle,
+				},
+				Rules: []admRegv1.RuleWithOperations{
+					{
+						Operations: []admRegv1.OperationType{admRegv1.Create, admRegv1.Update},
+						Rule: admRegv1.Rule{
+							APIGroups:   []string{"cert-manager.io"},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"certificates"},
+							Scope:       &scope,
+						},
+					},
+				},
+				FailurePolicy: &failurePolicy,
+				MatchPolicy:   &matchPolicy,
+				// the operator namespace is excluded, so that the operator
+				// can always be recovered there
+				NamespaceSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: corev1.LabelMetadataName, Operator: metav1.LabelSelectorOpNotIn, Values: []string{r.NS}},
+					},
+				},
+				ObjectSelector:          &metav1.LabelSelector{},
+				SideEffects:             &sideEffects,
+				TimeoutSeconds:          &timeout,
+				AdmissionReviewVersions: []string{"v1"},
+			},
+		},
+	}
+
+	for i := range instances {
+		if err := controllerutil.SetOwnerReference(&instances[i], desired, r.Scheme); err != nil {
+			return err
+		}
+	}
+
+	existing := &admRegv1.ValidatingWebhookConfiguration{}
+	err := r.Reader.Get(ctx, types.NamespacedName{Name: desired.Name}, existing)
+	if apiErrors.IsNotFound(err) {
+		logd.Info("Creating policy ValidatingWebhookConfiguration " + desired.Name)
+		return r.Client.Create(ctx, desired)
+	} else if err != nil {
+		return err
+	}
+	owners := existing.DeepCopy().OwnerReferences
+	for i := range instances {
+		if err := controllerutil.SetOwnerReference(&instances[i], existing, r.Scheme); err != nil {
+			return err
+		}
+	}
+	if equality.Semantic.DeepEqual(existing.Webhooks, desired.Webhooks) && equality.Semantic.DeepEqual(existing.OwnerReferences, owners) {
+		return nil
+	}
+	recordDriftCorrection("ValidatingWebhookConfiguration")
+	logd.Info("Updating policy ValidatingWebhookConfiguration " + desired.Name)
+	existing.Webhooks = desired.Webhooks
+	return r.Client.Update(ctx, existing)
+}
+
+// removePolicyWebhook removes the ValidatingWebhookConfiguration of the
+// policy webhook once there are no more policies
+func (r *CertificatePolicyReconciler) removePolicyWebhook(ctx context.Context) error {
+	existing := &admRegv1.ValidatingWebhookConfiguration{}
+	err := r.Reader.Get(ctx, types.NamespacedName{Name: res.PolicyWebhookName}, existing)
+	if apiErrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	logd.Info("Removing policy ValidatingWebhookConfiguration " + res.PolicyWebhookName)
+	return client.IgnoreNotFound(r.Client.Delete(ctx, existing))
+}
+
+// SetupWithManager sets up the controller with the Manager and registers the
+// policy webhook on the webhook server of the Manager. The policies and the
+// metadata of the namespaces are read from the cache by every replica serving
+// the webhook, so their informers are registered here rather than by the
+// controller, which only runs on the leader. Nothing is set up when the
+// CertificatePolicy CRD is not installed, e.g. when the operator was upgraded
+// without it
+func (r *CertificatePolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if _, err := mgr.GetCache().GetInformer(context.Background(), &operatorv1.CertificatePolicy{}); err != nil {
+		if meta.IsNoMatchError(err) {
+			logd.Info("The CertificatePolicy CRD is not installed, CertificatePolicies are not enforced")
+			return nil
+		}
+		return err
+	}
+	if _, err := mgr.GetCache().GetInformer(context.Background(), namespaceMetadata()); err != nil {
+		return err
+	}
+	mgr.GetWebhookServer().Register(res.PolicyWebhookPath, &webhook.Admission{
+		Handler: NewCertificatePolicyValidator(mgr.GetClient(), mgr.GetScheme()),
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("certificatepolicy_controller").
+		For(&operatorv1.CertificatePolicy{}).
+		// the webhook configuration is owned by the CertManagerConfigs
+		Watches(&operatorv1.CertManagerConfig{}, handler.EnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj client.Object) []reconcile.Request {
+				return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: res.PolicyWebhookName}}}
+			})).
+		Complete(r)
+}
+
+// PolicyWebhookCertificate serves the certificate of the policy webhook from
+// its serving secret, which is reloaded every policyWebhookCertReload so that
+// every replica picks up the renewals of the leader
+type PolicyWebhookCertificate struct {
+	Reader client.Reader
+	NS     string
+
+	mu     sync.Mutex
+	cert   *tls.Certificate
+	loaded time.Time
+}
+
+// TLSConfig sets the certificate of the webhook server
+func (c *PolicyWebhookCertificate) TLSConfig(config *tls.Config) {
+	config.GetCertificate = c.GetCertificate
+}
+
+// GetCertificate returns the serving certificate, the previous one is kept if
+// the secret can not be read
+func (c *PolicyWebhookCertificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cert != nil && time.Since(c.loaded) < policyWebhookCertReload {
+		return c.cert, nil
+	}
+	if c.Reader == nil {
+		return nil, errors.New("the policy webhook is not set up")
+	}
+
+	secret := &corev1.Secret{}
+	err := c.Reader.Get(context.Background(), types.NamespacedName{Name: res.PolicyWebhookName, Namespace: c.NS}, secret)
+	if err == nil {
+		var cert tls.Certificate
+		if cert, err = tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]); err == nil {
+			c.cert, c.loaded = &cert, time.Now()
+			return c.cert, nil
+		}
+	}
+	if c.cert != nil {
+		logd.Error(err, "Error reloading the policy webhook serving certificate, serving the previous one")
+		c.loaded = time.Now()
+		return c.cert, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,280 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	certmanagerv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/cert-manager/v1"
+	cmmeta "github.com/ibm/ibm-cert-manager-operator/v4/apis/meta.cert-manager/v1"
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+)
+
+// testCertificate returns a Certificate with the given spec changes applied
+func testCertificate(change func(*certmanagerv1.CertificateSpec)) *certmanagerv1.Certificate {
+	cert := &certmanagerv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test"},
+		Spec: certmanagerv1.CertificateSpec{
+			SecretName: "test",
+			CommonName: "test.example.com",
+			IssuerRef:  cmmeta.ObjectReference{Name: "ca"},
+		},
+	}
+	if change != nil {
+		change(&cert.Spec)
+	}
+	return cert
+}
+
+func TestCertificatePolicyViolations(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		name   string
+		spec   operatorv1.CertificatePolicySpec
+		cert   *certmanagerv1.Certificate
+		wantN  int
+		wantIn string
+	}{
+		{
+			name: "empty policy",
+			cert: testCertificate(nil),
+		},
+		{
+			name:   "default RSA key size below minimum",
+			spec:   operatorv1.CertificatePolicySpec{MinRSAKeySize: intPtr(3072)},
+			cert:   testCertificate(nil),
+			wantN:  1,
+			wantIn: "RSA private key size 2048 is smaller than 3072",
+		},
+		{
+			name: "default RSA key size at minimum",
+			spec: operatorv1.CertificatePolicySpec{MinRSAKeySize: intPtr(2048)},
+			cert: testCertificate(nil),
+		},
+		{
+			name: "explicit RSA key size above minimum",
+			spec: operatorv1.CertificatePolicySpec{MinRSAKeySize: intPtr(3072)},
+			cert: testCertificate(func(s *certmanagerv1.CertificateSpec) {
+				s.PrivateKey = &certmanagerv1.CertificatePrivateKey{Size: 4096}
+			}),
+		},
+		{
+			name:   "default algorithm not allowed",
+			spec:   operatorv1.CertificatePolicySpec{AllowedKeyAlgorithms: []string{"ECDSA"}},
+			cert:   testCertificate(nil),
+			wantN:  1,
+			wantIn: "private key algorithm RSA is not allowed, allowed algorithms are ECDSA",
+		},
+		{
+			name: "default ECDSA key size allowed",
+			spec: operatorv1.CertificatePolicySpec{AllowedECDSAKeySizes: []int{256, 384}},
+			cert: testCertificate(func(s *certmanagerv1.CertificateSpec) {
+				s.PrivateKey = &certmanagerv1.CertificatePrivateKey{Algorithm: certmanagerv1.ECDSAKeyAlgorithm}
+			}),
+		},
+		{
+			name: "ECDSA key size not allowed",
+			spec: operatorv1.CertificatePolicySpec{AllowedECDSAKeySizes: []int{384}},
+			cert: testCertificate(func(s *certmanagerv1.CertificateSpec) {
+				s.PrivateKey = &certmanagerv1.CertificatePrivateKey{Algorithm: certmanagerv1.ECDSAKeyAlgorithm, Size: 256}
+			}),
+			wantN:  1,
+			wantIn: "ECDSA private key size 256 is not allowed, allowed sizes are [384]",
+		},
+		{
+			name: "RSA rules do not apply to ECDSA keys",
+			spec: operatorv1.CertificatePolicySpec{MinRSAKeySize: intPtr(4096)},
+			cert: testCertificate(func(s *certmanagerv1.CertificateSpec) {
+				s.PrivateKey = &certmanagerv1.CertificatePrivateKey{Algorithm: certmanagerv1.ECDSAKeyAlgorithm}
+			}),
+		},
+		{
+			name:   "default duration longer than maximum",
+			spec:   operatorv1.CertificatePolicySpec{MaxDuration: &metav1.Duration{Duration: 30 * 24 * time.Hour}},
+			cert:   testCertificate(nil),
+			wantN:  1,
+			wantIn: "duration 2160h0m0s is longer than 720h0m0s",
+		},
+		{
+			name: "explicit duration within maximum",
+			spec: operatorv1.CertificatePolicySpec{MaxDuration: &metav1.Duration{Duration: 30 * 24 * time.Hour}},
+			cert: testCertificate(func(s *certmanagerv1.CertificateSpec) {
+				s.Duration = &metav1.Duration{Duration: 24 * time.Hour}
+			}),
+		},
+		{
+			name: "issuer kind defaults to Issuer",
+			spec: operatorv1.CertificatePolicySpec{AllowedIssuers: []operatorv1.CertificatePolicyIssuer{{Name: "ca"}}},
+			cert: testCertificate(nil),
+		},
+		{
+			name:   "Issuer not allowed by ClusterIssuer rule",
+			spec:   operatorv1.CertificatePolicySpec{AllowedIssuers: []operatorv1.CertificatePolicyIssuer{{Kind: "ClusterIssuer", Name: "ca"}}},
+			cert:   testCertificate(nil),
+			wantN:  1,
+			wantIn: "issuer " + issuerName("", "ca") + " is not allowed",
+		},
+		{
+			name: "any ClusterIssuer allowed",
+			spec: operatorv1.CertificatePolicySpec{AllowedIssuers: []operatorv1.CertificatePolicyIssuer{{Kind: "ClusterIssuer", Name: "*"}}},
+			cert: testCertificate(func(s *certmanagerv1.CertificateSpec) {
+				s.IssuerRef = cmmeta.ObjectReference{Kind: "ClusterIssuer", Name: "public"}
+			}),
+		},
+		{
+			name: "issuer of another group not allowed",
+			spec: operatorv1.CertificatePolicySpec{AllowedIssuers: []operatorv1.CertificatePolicyIssuer{{Name: "*"}}},
+			cert: testCertificate(func(s *certmanagerv1.CertificateSpec) {
+				s.IssuerRef = cmmeta.ObjectReference{Group: "example.com", Kind: "Issuer", Name: "ca"}
+			}),
+			wantN: 1,
+		},
+		{
+			name: "wildcard DNS names forbidden",
+			spec: operatorv1.CertificatePolicySpec{ForbidWildcardDNSNames: true},
+			cert: testCertificate(func(s *certmanagerv1.CertificateSpec) {
+				s.CommonName = "*.example.com"
+				s.DNSNames = []string{"*.example.com", "www.example.com", "*.test.example.com"}
+			}),
+			wantN:  3,
+			wantIn: "wildcard DNS name *.test.example.com is not allowed",
+		},
+		{
+			name: "no wildcard DNS names",
+			spec: operatorv1.CertificatePolicySpec{ForbidWildcardDNSNames: true},
+			cert: testCertificate(func(s *certmanagerv1.CertificateSpec) {
+				s.DNSNames = []string{"www.example.com"}
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := certificatePolicyViolations(&tt.spec, tt.cert)
+			if len(violations) != tt.wantN {
+				t.Fatalf("got violations %q, want %d", violations, tt.wantN)
+			}
+			if tt.wantIn != "" && !containsString(violations, tt.wantIn) {
+				t.Errorf("got violations %q, want %q", violations, tt.wantIn)
+			}
+		})
+	}
+}
+
+func TestEvaluateCertificatePolicies(t *testing.T) {
+	minRSAKeySize := 4096
+	policy := func(name, mode string, selector *metav1.LabelSelector) operatorv1.CertificatePolicy {
+		return operatorv1.CertificatePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       operatorv1.CertificatePolicySpec{Mode: mode, NamespaceSelector: selector, MinRSAKeySize: &minRSAKeySize},
+		}
+	}
+	violation := "RSA private key size 2048 is smaller than 4096"
+	production := &metav1.LabelSelector{MatchLabels: map[string]string{"env": "production"}}
+
+	tests := []struct {
+		name            string
+		policies        []operatorv1.CertificatePolicy
+		namespaceLabels map[string]string
+		wantDenied      []string
+		wantWarnings    []string
+	}{
+		{
+			name: "no policies",
+		},
+		{
+			name:       "mode defaults to Enforce",
+			policies:   []operatorv1.CertificatePolicy{policy("strict", "", nil)},
+			wantDenied: []string{"CertificatePolicy strict: " + violation},
+		},
+		{
+			name:       "Enforce",
+			policies:   []operatorv1.CertificatePolicy{policy("strict", operatorv1.CertificatePolicyEnforce, nil)},
+			wantDenied: []string{"CertificatePolicy strict: " + violation},
+		},
+		{
+			name:         "Audit",
+			policies:     []operatorv1.CertificatePolicy{policy("audit", operatorv1.CertificatePolicyAudit, nil)},
+			wantWarnings: []string{"CertificatePolicy audit: " + violation},
+		},
+		{
+			name: "Audit and Enforce",
+			policies: []operatorv1.CertificatePolicy{
+				policy("audit", operatorv1.CertificatePolicyAudit, nil),
+				policy("strict", operatorv1.CertificatePolicyEnforce, nil),
+			},
+			wantDenied:   []string{"CertificatePolicy strict: " + violation},
+			wantWarnings: []string{"CertificatePolicy audit: " + violation},
+		},
+		{
+			name:            "namespace selected",
+			policies:        []operatorv1.CertificatePolicy{policy("production", "", production)},
+			namespaceLabels: map[string]string{"env": "production"},
+			wantDenied:      []string{"CertificatePolicy production: " + violation},
+		},
+		{
+			name:            "namespace not selected",
+			policies:        []operatorv1.CertificatePolicy{policy("production", "", production)},
+			namespaceLabels: map[string]string{"env": "test"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denied, warnings := evaluateCertificatePolicies(tt.policies, tt.namespaceLabels, testCertificate(nil))
+			if !reflect.DeepEqual(denied, tt.wantDenied) {
+				t.Errorf("denied = %q, want %q", denied, tt.wantDenied)
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
+
+func TestIsSelfTestRequest(t *testing.T) {
+	dryRun, persisted := true, false
+	selfTest := selfTestCertificate("default", false)
+
+	tests := []struct {
+		name   string
+		dryRun *bool
+		cert   *certmanagerv1.Certificate
+		want   bool
+	}{
+		{name: "self-test", dryRun: &dryRun, cert: selfTest, want: true},
+		{name: "self-test name persisted", dryRun: &persisted, cert: selfTest},
+		{name: "self-test name without dry-run", cert: selfTest},
+		{name: "other Certificate with dry-run", dryRun: &dryRun, cert: testCertificate(nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{DryRun: tt.dryRun}}
+			if got := isSelfTestRequest(req, tt.cert); got != tt.want {
+				t.Errorf("isSelfTestRequest = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -26,10 +26,15 @@ import (
 	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
 	admRegv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apiextensionclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -56,15 +61,21 @@ type CertManagerReconciler struct {
 	Scheme       *runtime.Scheme
 	Recorder     record.EventRecorder
 	NS           string
+
+	permissions permissionCheck
+	circuit     webhookCircuit
+	selfTest    webhookSelfTest
 }
 
 //+kubebuilder:rbac:groups=operator.ibm.com,resources=certmanagerconfigs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=operator.ibm.com,resources=certmanagerconfigs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operator.ibm.com,resources=certmanagerconfigs/finalizers,verbs=update
+//+kubebuilder:rbac:groups=operator.ibm.com,resources=certificatepolicies,verbs=get;list;watch
 
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 
 //+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterrolebindings;clusterroles;rolebindings;roles,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterroles;roles,verbs=escalate;bind
 //+kubebuilder:rbac:groups="admissionregistration.k8s.io",resources=validatingwebhookconfigurations,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="admissionregistration.k8s.io",resources=mutatingwebhookconfigurations,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="apiregistration.k8s.io",resources=apiservices,verbs=get;list;watch;create;update;patch;delete
@@ -80,7 +91,7 @@ type CertManagerReconciler struct {
 //+kubebuilder:rbac:groups="cert-manager.io",resources=certificaterequests/finalizers,verbs=update
 //+kubebuilder:rbac:groups="cert-manager.io",resources=certificaterequests/status,verbs=update
 //+kubebuilder:rbac:groups="cert-manager.io",resources=signers,verbs=approve
-//+kubebuilder:rbac:groups="cert-manager.io",resources=clusterissuers,verbs=get;list;watch;update
+//+kubebuilder:rbac:groups="cert-manager.io",resources=clusterissuers,verbs=get;list;watch;create;update;delete
 //+kubebuilder:rbac:groups="cert-manager.io",resources=clusterissuers/status,verbs=update
 //+kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;watch;create;update;patch
 //+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete;deletecollection
@@ -92,15 +103,23 @@ type CertManagerReconciler struct {
 //+kubebuilder:rbac:groups=cert-manager.io,resources=issuers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=cert-manager.io,resources=issuers/finalizers,verbs=update
 
+//+kubebuilder:rbac:groups="autoscaling",resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="autoscaling.k8s.io",resources=verticalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
+
+//+kubebuilder:rbac:groups="monitoring.coreos.com",resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
+
 //+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups="",resources=endpoints,verbs=get
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=events,verbs=get;create;update;patch
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;create;update
 
 //+kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses;httproutes,verbs=get;list;watch;create;delete;update
 //+kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses/finalizers,verbs=update
+//+kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 
 //+kubebuilder:rbac:groups="networking.x-k8s.io",resources=httproutes,verbs=get;list;watch;create;delete;update
 //+kubebuilder:rbac:groups="networking.x-k8s.io",resources=gateways,verbs=get;list;watch
@@ -110,7 +129,7 @@ type CertManagerReconciler struct {
 
 //+kubebuilder:rbac:groups="auditregistration.k8s.io",resources=auditsinks,verbs=get;list;watch;update
 
-//+kubebuilder:rbac:groups="authorization.k8s.io",resources=subjectaccessreviews,verbs=create
+//+kubebuilder:rbac:groups="authorization.k8s.io",resources=subjectaccessreviews;selfsubjectaccessreviews,verbs=create
 
 //+kubebuilder:rbac:groups="ibmcpcs.ibm.com",resources=secretshares,verbs=create;get;list;watch
 
@@ -157,7 +176,11 @@ func (r *CertManagerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		logd.Error(nil, "Accept license by changing .spec.license.accept to true in the CertManagerConfig CR. This message will keep showing until then")
 	}
 
-	if err := r.updateLabels(ctx); err != nil {
+	r.checkPermissions(instance)
+
+	err = r.updateLabels(ctx)
+	recordReconcileStep(stepLabels, err)
+	if err != nil {
 		logd.Error(err, "Error with updating cert-manager labels, requeueing")
 		r.updateStatus(instance, "Error updating cert-manager labels")
 		r.updateEvent(instance, err.Error(), corev1.EventTypeWarning, "LabelsFailed")
@@ -165,7 +188,9 @@ func (r *CertManagerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	// Check Prerequisites
-	if err := r.PreReqs(instance); err != nil {
+	err = r.PreReqs(instance)
+	recordReconcileStep(stepPrereqs, err)
+	if err != nil {
 		logd.Error(err, "One or more prerequisites not met, requeueing")
 		r.updateStatus(instance, "Error deploying cert-manager, prereqs not met")
 		r.updateEvent(instance, err.Error(), corev1.EventTypeWarning, "PrereqsFailed")
@@ -173,15 +198,39 @@ func (r *CertManagerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 	r.updateEvent(instance, "All prerequisites for deploying cert-manager service found", corev1.EventTypeNormal, "PrereqsMet")
 
+	err = r.cleanup(instance)
+	recordReconcileStep(stepCleanup, err)
+	if err != nil {
+		logd.Error(err, "Error cleaning up resources of older releases, requeueing")
+		r.updateEvent(instance, err.Error(), corev1.EventTypeWarning, "CleanupFailed")
+		r.updateStatus(instance, "Error cleaning up resources of older releases")
+		return ctrl.Result{Requeue: true}, nil
+	}
+
 	// Check Deployment itself
-	if err := r.deployments(instance); err != nil {
+	err = r.deployments(instance)
+	recordReconcileStep(stepDeploy, err)
+	r.updateOperandReadiness(instance)
+	if err != nil {
 		logd.Error(err, "Error with deploying cert-manager, requeueing")
 		r.updateEvent(instance, err.Error(), corev1.EventTypeWarning, "Failed")
 		r.updateStatus(instance, "Error deploying cert-manager")
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	if err := r.updateVersion(instance); err != nil {
+	r.updateEffectiveResources(instance)
+	r.updateScopeCondition(instance)
+
+	defaultIssuersReady, err := r.defaultIssuers(instance)
+	recordReconcileStep(stepIssuers, err)
+	if err != nil {
+		logd.Error(err, "Error reconciling the default issuers")
+		r.updateEvent(instance, err.Error(), corev1.EventTypeWarning, "DefaultIssuersFailed")
+	}
+
+	err = r.updateVersion(instance)
+	recordReconcileStep(stepVersion, err)
+	if err != nil {
 		logd.Error(err, "Error updating certmanagerconfig cr")
 		r.updateEvent(instance, err.Error(), corev1.EventTypeWarning, "Failed")
 		r.updateStatus(instance, "Error updating version")
@@ -190,7 +239,21 @@ func (r *CertManagerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 
 	r.updateEvent(instance, "Deployed cert-manager successfully", corev1.EventTypeNormal, "Deployed")
 	r.updateStatus(instance, "Successfully deployed cert-manager")
-	return ctrl.Result{}, nil
+	lastSuccessfulReconcile.SetToCurrentTime()
+	// reconcile periodically so that permissions revoked after startup are
+	// reported and the webhooks are tested, and sooner while the webhook
+	// circuit breaker is pending or the default issuers are not ready
+	requeueAfter := permissionCheckInterval
+	if instance.Spec.Webhook {
+		requeueAfter = webhookSelfTestInterval
+	}
+	if r.circuit.recheck > 0 && r.circuit.recheck < requeueAfter {
+		requeueAfter = r.circuit.recheck
+	}
+	if !defaultIssuersReady && defaultIssuersRecheck < requeueAfter {
+		requeueAfter = defaultIssuersRecheck
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 func (r *CertManagerReconciler) updateEvent(instance *operatorv1.CertManagerConfig, message, event, reason string) {
@@ -206,11 +269,111 @@ func (r *CertManagerReconciler) updateStatus(instance *operatorv1.CertManagerCon
 	}
 }
 
+// updateEffectiveResources records the resource requirements deployed for each
+// operand in the status of the CR
+func (r *CertManagerReconciler) updateEffectiveResources(instance *operatorv1.CertManagerConfig) {
+	effective := &operatorv1.EffectiveResources{
+		CertManagerController: operandResources(instance, res.CertManagerControllerName),
+	}
+	if _, ok := res.ProfileResources[instance.Spec.Profile]; ok {
+		effective.Profile = instance.Spec.Profile
+	}
+	if instance.Spec.Webhook {
+		effective.CertManagerWebhook = operandResources(instance, res.CertManagerWebhookName)
+		effective.CertManagerCAInjector = operandResources(instance, res.CertManagerCainjectorName)
+	}
+	if !equality.Semantic.DeepEqual(instance.Status.EffectiveResources, effective) {
+		instance.Status.EffectiveResources = effective
+		if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+			logd.Error(err, "Error updating instance status")
+		}
+	}
+}
+
+// updateOperandReadiness reports whether the replicas of each deployed operand
+// are ready
+func (r *CertManagerReconciler) updateOperandReadiness(instance *operatorv1.CertManagerConfig) {
+	operands := map[string]bool{
+		res.CertManagerControllerName: true,
+		res.CertManagerWebhookName:    instance.Spec.Webhook,
+		res.CertManagerCainjectorName: instance.Spec.Webhook,
+	}
+	for name, deployed := range operands {
+		if !deployed {
+			operandReady.DeleteLabelValues(name)
+			continue
+		}
+		deploy := &appsv1.Deployment{}
+		if err := r.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: r.NS}, deploy); err != nil {
+			operandReady.WithLabelValues(name).Set(0)
+			continue
+		}
+		desired := int32(1)
+		if deploy.Spec.Replicas != nil {
+			desired = *deploy.Spec.Replicas
+		}
+		if deploy.Status.ReadyReplicas >= desired {
+			operandReady.WithLabelValues(name).Set(1)
+		} else {
+			operandReady.WithLabelValues(name).Set(0)
+		}
+	}
+}
+
+// updateScopeCondition reports in the status whether cert-manager-controller
+// is namespace scoped and which features are unavailable because of it
+func (r *CertManagerReconciler) updateScopeCondition(instance *operatorv1.CertManagerConfig) {
+	condition := metav1.Condition{
+		Type:    operatorv1.ConditionNamespaceScoped,
+		Status:  metav1.ConditionFalse,
+		Reason:  "AllNamespaces",
+		Message: "cert-manager-controller watches all namespaces",
+	}
+	if namespaceScoped(instance) {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "WatchNamespaces"
+		condition.Message = fmt.Sprintf("cert-manager-controller only watches namespaces %s, the following features are unavailable: %s",
+			strings.Join(instance.Spec.WatchNamespaces, ", "), strings.Join(res.NamespaceScopedUnavailableFeatures, ", "))
+	}
+	r.setCondition(instance, condition)
+}
+
+// setCondition sets a condition in the status of the CR, updating the status
+// only when the condition changed
+func (r *CertManagerReconciler) setCondition(instance *operatorv1.CertManagerConfig, condition metav1.Condition) {
+	condition.ObservedGeneration = instance.Generation
+	existing := meta.FindStatusCondition(instance.Status.Conditions, condition.Type)
+	if existing != nil && existing.Status == condition.Status && existing.Reason == condition.Reason &&
+		existing.Message == condition.Message && existing.ObservedGeneration == condition.ObservedGeneration {
+		return
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+	if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+		logd.Error(err, "Error updating instance status")
+	}
+}
+
+// removeCondition removes a condition from the status of the CR, e.g. when the
+// feature it describes is disabled
+func (r *CertManagerReconciler) removeCondition(instance *operatorv1.CertManagerConfig, conditionType string) {
+	if meta.FindStatusCondition(instance.Status.Conditions, conditionType) == nil {
+		return
+	}
+	meta.RemoveStatusCondition(&instance.Status.Conditions, conditionType)
+	if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+		logd.Error(err, "Error updating instance status")
+	}
+}
+
 func (r *CertManagerReconciler) PreReqs(instance *operatorv1.CertManagerConfig) error {
 	if err := checkRbac(instance, r.Scheme, r.Client, r.NS); err != nil {
 		logd.V(2).Info("Checking RBAC failed")
 		return err
 	}
+	if err := pruneRbac(instance, r.Client, r.Recorder, r.NS); err != nil {
+		logd.V(2).Info("Pruning RBAC failed")
+		return err
+	}
 	return nil
 }
 
@@ -219,13 +382,25 @@ func (r *CertManagerReconciler) deployments(instance *operatorv1.CertManagerConf
 		return err
 	}
 
-	if err := removeDeploy(r.Kubeclient, res.ConfigmapWatcherName, r.NS); err != nil {
-		return err
-	}
-
 	if instance.Spec.Webhook {
+		// Issue the serving certificate when the operator manages it
+		caBundle, err := r.webhookTLS(instance)
+		if err != nil {
+			recordReconcileStep(stepWebhook, err)
+			return err
+		}
+		circuitAction, err := r.webhookCircuitBreaker(instance)
+		if err != nil {
+			recordReconcileStep(stepWebhook, err)
+			return err
+		}
 		// Check webhook prerequisites
-		if err := webhookPrereqs(instance, r.Scheme, r.Client, r.Reader, r.NS); err != nil {
+		err = webhookPrereqs(instance, r.Scheme, r.Client, r.Recorder, r.NS, caBundle, circuitAction)
+		if err == nil && caBundle != nil {
+			err = r.injectConversionCABundle(caBundle)
+		}
+		recordReconcileStep(stepWebhook, err)
+		if err != nil {
 			return err
 		}
 		// Deploy webhook and cainjector
@@ -235,8 +410,12 @@ func (r *CertManagerReconciler) deployments(instance *operatorv1.CertManagerConf
 		if err := webhookDeploy(instance, r.Client, r.Kubeclient, r.Scheme, r.NS); err != nil {
 			return err
 		}
+		r.testWebhook(instance, circuitAction)
 	} else {
 		// Specified to not deploy the webhook, remove them if they exist
+		r.circuit = webhookCircuit{}
+		r.selfTest = webhookSelfTest{}
+		r.removeCondition(instance, operatorv1.ConditionWebhookFunctional)
 		webhook := removeDeploy(r.Kubeclient, res.CertManagerWebhookName, res.DeployNamespace)
 		cainjector := removeDeploy(r.Kubeclient, res.CertManagerCainjectorName, res.DeployNamespace)
 		if !errors.IsNotFound(webhook) {
@@ -248,10 +427,22 @@ func (r *CertManagerReconciler) deployments(instance *operatorv1.CertManagerConf
 			return cainjector
 		}
 		// Remove webhook prerequisites
-		if err := removeWebhookPrereqs(r.Client, r.NS); err != nil {
+		err := removeWebhookPrereqs(r.Client, r.NS)
+		recordReconcileStep(stepWebhook, err)
+		if err != nil {
 			return err
 		}
 	}
+
+	if err := autoscaling(instance, r.Scheme, r.Client, r.Reader, r.Kubeclient, r.NS); err != nil {
+		return err
+	}
+	if err := networkPolicies(instance, r.Scheme, r.Client, r.NS); err != nil {
+		return err
+	}
+	if err := monitoring(instance, r.Scheme, r.Client, r.Reader, r.Kubeclient, r.NS); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -425,6 +616,7 @@ func (r *CertManagerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		klog.Errorf("Fail to create CertManager Instance: %v", err)
 		return err
 	}
+	r.logMissingPermissions()
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("certmanagerconfig_controller").
 		For(&operatorv1.CertManagerConfig{}).
@@ -437,5 +629,7 @@ func (r *CertManagerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&admRegv1.MutatingWebhookConfiguration{}).
 		Owns(&admRegv1.ValidatingWebhookConfiguration{}).
 		Owns(&corev1.Service{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
+		Owns(&networkingv1.NetworkPolicy{}).
 		Complete(r)
 }
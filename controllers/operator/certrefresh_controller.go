@@ -0,0 +1,290 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	certmanagerv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/cert-manager/v1"
+	cmmeta "github.com/ibm/ibm-cert-manager-operator/v4/apis/meta.cert-manager/v1"
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+const (
+	// certRefreshRate is the number of leaf certificates re-issued per
+	// second, certRefreshBurst the number re-issued at once
+	certRefreshRate  = 1
+	certRefreshBurst = 10
+
+	certRefreshRequeue = 30 * time.Second
+)
+
+var clusterIssuerListGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuerList"}
+
+// CertRefreshReconciler re-issues the leaf certificates of the CA
+// certificates listed in spec.refreshCertsBasedOnCA when the CA keypair
+// changes, if spec.enableCertRefresh is set
+type CertRefreshReconciler struct {
+	Client   client.Client
+	Reader   client.Reader
+	Recorder record.EventRecorder
+	// TLSSecrets is the cache of the TLS secrets of the cluster
+	TLSSecrets cache.Cache
+
+	limiter *rate.Limiter
+}
+
+// Reconcile is called for a CA Certificate. It re-issues the leaf
+// Certificates issued by an Issuer or ClusterIssuer using the secret of the
+// CA, whose ca.crt is not the current CA
+func (r *CertRefreshReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	instance, err := r.refreshingInstance(req.NamespacedName)
+	if err != nil || instance == nil {
+		return ctrl.Result{}, err
+	}
+
+	ca := &certmanagerv1.Certificate{}
+	if err := r.Client.Get(ctx, req.NamespacedName, ca); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	caSecret := &corev1.Secret{}
+	if err := r.TLSSecrets.Get(ctx, types.NamespacedName{Name: ca.Spec.SecretName, Namespace: ca.Namespace}, caSecret); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if len(caSecret.Data[corev1.TLSCertKey]) == 0 {
+		// not issued yet
+		return ctrl.Result{}, nil
+	}
+
+	leaves, err := r.leafCertificates(ctx, instance, ca)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	for i := range leaves {
+		leaf := &leaves[i]
+		if issuing(leaf) {
+			continue
+		}
+		leafSecret := &corev1.Secret{}
+		if err := r.TLSSecrets.Get(ctx, types.NamespacedName{Name: leaf.Spec.SecretName, Namespace: leaf.Namespace}, leafSecret); err != nil {
+			if apiErrors.IsNotFound(err) {
+				// cert-manager issues it with the current CA
+				continue
+			}
+			return ctrl.Result{}, err
+		}
+		leafCA := leafSecret.Data[corev1.ServiceAccountRootCAKey]
+		if len(leafCA) == 0 || bytes.Equal(leafCA, caSecret.Data[corev1.TLSCertKey]) || bytes.Equal(leafCA, caSecret.Data[corev1.ServiceAccountRootCAKey]) {
+			continue
+		}
+		if !r.limiter.Allow() {
+			logd.V(1).Info("Rate limiting the refresh of leaf certificates", "ca", req.NamespacedName)
+			return ctrl.Result{RequeueAfter: certRefreshRequeue}, nil
+		}
+		if err := r.reissue(ctx, leaf, ca); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// refreshingInstance returns the CertManagerConfig refreshing the leaf
+// certificates of the CA, or nil if there is none
+func (r *CertRefreshReconciler) refreshingInstance(ca types.NamespacedName) (*operatorv1.CertManagerConfig, error) {
+	instances := &operatorv1.CertManagerConfigList{}
+	if err := r.Client.List(context.TODO(), instances); err != nil {
+		return nil, err
+	}
+	for i := range instances.Items {
+		instance := &instances.Items[i]
+		if instance.Spec.EnableCertRefresh == nil || !*instance.Spec.EnableCertRefresh {
+			continue
+		}
+		for _, c := range instance.Spec.RefreshCertsBasedOnCA {
+			if c.CertName == ca.Name && c.Namespace == ca.Namespace {
+				return instance, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// leafCertificates returns the Certificates issued by the Issuers and
+// ClusterIssuers signing with the secret of the CA
+func (r *CertRefreshReconciler) leafCertificates(ctx context.Context, instance *operatorv1.CertManagerConfig, ca *certmanagerv1.Certificate) ([]certmanagerv1.Certificate, error) {
+	issuers := &certmanagerv1.IssuerList{}
+	// read from API server directly, issuers are not cached
+	if err := r.Reader.List(ctx, issuers, client.InNamespace(ca.Namespace)); err != nil {
+		return nil, err
+	}
+	issuerNames := make(map[string]bool)
+	for _, issuer := range issuers.Items {
+		if issuer.Spec.CA != nil && issuer.Spec.CA.SecretName == ca.Spec.SecretName {
+			issuerNames[issuer.Name] = true
+		}
+	}
+
+	// ClusterIssuers read their secret from the cluster resource namespace
+	clusterIssuerNames := make(map[string]bool)
+	resourceNS := instance.Spec.ResourceNS
+	if resourceNS == "" {
+		resourceNS = res.DeployNamespace
+	}
+	if ca.Namespace == resourceNS {
+		clusterIssuers := &unstructured.UnstructuredList{}
+		clusterIssuers.SetGroupVersionKind(clusterIssuerListGVK)
+		if err := r.Reader.List(ctx, clusterIssuers); err != nil && !apiErrors.IsNotFound(err) {
+			return nil, err
+		}
+		for _, issuer := range clusterIssuers.Items {
+			secretName, _, _ := unstructured.NestedString(issuer.Object, "spec", "ca", "secretName")
+			if secretName == ca.Spec.SecretName {
+				clusterIssuerNames[issuer.GetName()] = true
+			}
+		}
+	}
+	if len(issuerNames) == 0 && len(clusterIssuerNames) == 0 {
+		return nil, nil
+	}
+
+	certificates := &certmanagerv1.CertificateList{}
+	if err := r.Client.List(ctx, certificates); err != nil {
+		return nil, err
+	}
+	var leaves []certmanagerv1.Certificate
+	for _, cert := range certificates.Items {
+		if cert.Namespace == ca.Namespace && cert.Name == ca.Name {
+			continue
+		}
+		switch cert.Spec.IssuerRef.Kind {
+		case "", "Issuer":
+			if cert.Namespace == ca.Namespace && issuerNames[cert.Spec.IssuerRef.Name] {
+				leaves = append(leaves, cert)
+			}
+		case "ClusterIssuer":
+			if clusterIssuerNames[cert.Spec.IssuerRef.Name] {
+				leaves = append(leaves, cert)
+			}
+		}
+	}
+	return leaves, nil
+}
+
+// issuing returns true if cert-manager is already issuing the certificate
+func issuing(cert *certmanagerv1.Certificate) bool {
+	for _, c := range cert.Status.Conditions {
+		if c.Type == certmanagerv1.CertificateConditionIssuing && c.Status == cmmeta.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// reissue triggers the issuance of the certificate the way cmctl renew does,
+// by setting its Issuing condition
+func (r *CertRefreshReconciler) reissue(ctx context.Context, leaf, ca *certmanagerv1.Certificate) error {
+	setCertificateCondition(leaf, certmanagerv1.CertificateConditionIssuing, cmmeta.ConditionTrue, "ManuallyTriggered",
+		fmt.Sprintf("Certificate re-issuance triggered by the renewal of CA %s/%s", ca.Namespace, ca.Name))
+	if err := r.Client.Status().Update(ctx, leaf); err != nil {
+		return err
+	}
+	logd.Info("Refreshing leaf certificate", "certificate", leaf.Namespace+"/"+leaf.Name, "ca", ca.Namespace+"/"+ca.Name)
+	r.Recorder.Event(leaf, corev1.EventTypeNormal, "CertificateRefreshed",
+		fmt.Sprintf("Re-issuing the certificate, its CA %s/%s was renewed", ca.Namespace, ca.Name))
+	return nil
+}
+
+// setCertificateCondition replaces the condition of the type in the status of
+// the certificate, the way cert-manager does. The last transition time is
+// kept when the status does not change
+func setCertificateCondition(cert *certmanagerv1.Certificate, conditionType certmanagerv1.CertificateConditionType, status cmmeta.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+	condition := certmanagerv1.CertificateCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: &now,
+		ObservedGeneration: cert.Generation,
+	}
+	for i, c := range cert.Status.Conditions {
+		if c.Type != conditionType {
+			continue
+		}
+		if c.Status == status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+		cert.Status.Conditions[i] = condition
+		return
+	}
+	cert.Status.Conditions = append(cert.Status.Conditions, condition)
+}
+
+// SetupWithManager sets up the controller with the Manager. A change of a
+// CertManagerConfig reconciles all the CA certificates it lists, a change of
+// the secret of a certificate reconciles the certificate. The secrets are
+// watched through TLSSecrets
+func (r *CertRefreshReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.limiter = rate.NewLimiter(certRefreshRate, certRefreshBurst)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("certrefresh_controller").
+		For(&certmanagerv1.Certificate{}).
+		WatchesRawSource(source.Kind(r.TLSSecrets, &corev1.Secret{}), handler.EnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj client.Object) []reconcile.Request {
+				certName, ok := obj.GetAnnotations()[certificateNameAnnotation]
+				if !ok {
+					return nil
+				}
+				ca := types.NamespacedName{Name: certName, Namespace: obj.GetNamespace()}
+				if instance, err := r.refreshingInstance(ca); err != nil || instance == nil {
+					return nil
+				}
+				return []reconcile.Request{{NamespacedName: ca}}
+			})).
+		Watches(&operatorv1.CertManagerConfig{}, handler.EnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj client.Object) []reconcile.Request {
+				instance, ok := obj.(*operatorv1.CertManagerConfig)
+				if !ok {
+					return nil
+				}
+				var requests []reconcile.Request
+				for _, c := range instance.Spec.RefreshCertsBasedOnCA {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: c.CertName, Namespace: c.Namespace}})
+				}
+				return requests
+			})).
+		Complete(r)
+}
@@ -0,0 +1,89 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	certmanagerv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/cert-manager/v1"
+	cmmeta "github.com/ibm/ibm-cert-manager-operator/v4/apis/meta.cert-manager/v1"
+)
+
+func TestSetCertificateCondition(t *testing.T) {
+	earlier := metav1.NewTime(time.Now().Add(-time.Hour))
+	ready := certmanagerv1.CertificateCondition{Type: certmanagerv1.CertificateConditionReady, Status: cmmeta.ConditionTrue, LastTransitionTime: &earlier}
+
+	tests := []struct {
+		name           string
+		conditions     []certmanagerv1.CertificateCondition
+		wantTransition bool
+		wantLen        int
+	}{
+		{
+			name:           "no Issuing condition",
+			conditions:     []certmanagerv1.CertificateCondition{ready},
+			wantTransition: true,
+			wantLen:        2,
+		},
+		{
+			name: "Issuing after a failed issuance",
+			conditions: []certmanagerv1.CertificateCondition{ready, {
+				Type: certmanagerv1.CertificateConditionIssuing, Status: cmmeta.ConditionFalse, Reason: "Failed", LastTransitionTime: &earlier,
+			}},
+			wantTransition: true,
+			wantLen:        2,
+		},
+		{
+			name: "already Issuing",
+			conditions: []certmanagerv1.CertificateCondition{{
+				Type: certmanagerv1.CertificateConditionIssuing, Status: cmmeta.ConditionTrue, Reason: "Renewing", LastTransitionTime: &earlier,
+			}, ready},
+			wantTransition: false,
+			wantLen:        2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := &certmanagerv1.Certificate{Status: certmanagerv1.CertificateStatus{Conditions: tt.conditions}}
+			setCertificateCondition(cert, certmanagerv1.CertificateConditionIssuing, cmmeta.ConditionTrue, "ManuallyTriggered", "renewed")
+
+			var issuingConditions []certmanagerv1.CertificateCondition
+			for _, c := range cert.Status.Conditions {
+				if c.Type == certmanagerv1.CertificateConditionIssuing {
+					issuingConditions = append(issuingConditions, c)
+				}
+			}
+			if len(issuingConditions) != 1 {
+				t.Fatalf("got %d Issuing conditions, want 1", len(issuingConditions))
+			}
+			c := issuingConditions[0]
+			if c.Status != cmmeta.ConditionTrue || c.Reason != "ManuallyTriggered" {
+				t.Errorf("Issuing condition = %s/%s, want True/ManuallyTriggered", c.Status, c.Reason)
+			}
+			if transitioned := !c.LastTransitionTime.Equal(&earlier); transitioned != tt.wantTransition {
+				t.Errorf("last transition time changed = %t, want %t", transitioned, tt.wantTransition)
+			}
+			if len(cert.Status.Conditions) != tt.wantLen {
+				t.Errorf("got %d conditions, want %d", len(cert.Status.Conditions), tt.wantLen)
+			}
+		})
+	}
+}
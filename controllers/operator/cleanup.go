@@ -0,0 +1,114 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	apiRegv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+// cleanupStep removes resources left over by an older release of the
+// operator. Steps must be idempotent: a step runs again if recording its
+// completion in the status fails
+type cleanupStep struct {
+	name string
+	run  func(r *CertManagerReconciler) error
+}
+
+// cleanupSteps are run in order until each of them completes once. Add new
+// steps at the end and never reorder or rename the existing ones, their name
+// is recorded in the status of the CR
+var cleanupSteps = []cleanupStep{
+	{name: "remove-configmap-watcher", run: removeConfigmapWatcher},
+	{name: "remove-old-webhook-secret", run: removeOldSecret},
+	{name: "remove-webhook-apiservice", run: removeWebhookAPIService},
+}
+
+// cleanup runs the cleanup steps which have not completed yet, recording each
+// completed step in the status. It stops at the first failing step so that
+// the order of the steps is kept
+func (r *CertManagerReconciler) cleanup(instance *operatorv1.CertManagerConfig) error {
+	completed := make(map[string]bool, len(instance.Status.CompletedCleanupSteps))
+	for _, id := range instance.Status.CompletedCleanupSteps {
+		completed[id] = true
+	}
+	for _, step := range cleanupSteps {
+		if completed[step.name] {
+			continue
+		}
+		logd.Info("Running cleanup step " + step.name)
+		if err := step.run(r); err != nil {
+			return err
+		}
+		instance.Status.CompletedCleanupSteps = append(instance.Status.CompletedCleanupSteps, step.name)
+		if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeConfigmapWatcher removes the configmap-watcher deployment, which is no
+// longer part of cert-manager
+func removeConfigmapWatcher(r *CertManagerReconciler) error {
+	return removeDeploy(r.Kubeclient, res.ConfigmapWatcherName, r.NS)
+}
+
+// removeOldSecret removes the serving secret of cert-manager-webhook created
+// by older releases, which cannot be injected directly. cert-manager-webhook
+// generates a new one
+func removeOldSecret(r *CertManagerReconciler) error {
+	secret := &corev1.Secret{}
+	// read from API server directly since there is probably more overhead to
+	// set up informers and cache just for one secret
+	err := r.Reader.Get(context.Background(), types.NamespacedName{Name: res.WebhookServingSecret, Namespace: r.NS}, secret)
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if _, ok := secret.Annotations["cert-manager.io/allow-direct-injection"]; !ok {
+		if err := r.Client.Delete(context.Background(), secret); err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeWebhookAPIService removes the APIService of the certmanager.k8s.io
+// webhook API, which is served by neither the webhook nor the operator
+func removeWebhookAPIService(r *CertManagerReconciler) error {
+	apiService := &apiRegv1.APIService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: res.APISvcName,
+		},
+	}
+	if err := r.Client.Delete(context.Background(), apiService); err != nil && !apiErrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	certmanagerv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/cert-manager/v1"
+	cmmeta "github.com/ibm/ibm-cert-manager-operator/v4/apis/meta.cert-manager/v1"
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+const (
+	defaultIssuerName        = "ibm-cert-manager-default-issuer"
+	defaultIssuerCADuration  = 5 * 365 * 24 * time.Hour
+	defaultIssuerRenewBefore = 30 * 24 * time.Hour
+
+	// defaultIssuersRecheck is how soon the chain is reconciled again while
+	// one of its links is not ready
+	defaultIssuersRecheck = 30 * time.Second
+)
+
+var clusterIssuerGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}
+
+// defaultIssuers reconciles the chain of default issuers of spec.defaultIssuers:
+// a self-signed ClusterIssuer, a CA Certificate it issues in the cluster
+// resource namespace and a CA ClusterIssuer signing with its secret. It
+// reports the readiness of each link in the status and returns true once
+// they are all ready, or if the chain is disabled
+func (r *CertManagerReconciler) defaultIssuers(instance *operatorv1.CertManagerConfig) (bool, error) {
+	spec := instance.Spec.DefaultIssuers
+	if spec == nil || !spec.Enabled {
+		if instance.Status.DefaultIssuers == nil {
+			return true, nil
+		}
+		if err := r.removeDefaultIssuers(); err != nil {
+			return true, err
+		}
+		instance.Status.DefaultIssuers = nil
+		if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+			logd.Error(err, "Error updating instance status")
+		}
+		r.removeCondition(instance, operatorv1.ConditionDefaultIssuersReady)
+		return true, nil
+	}
+
+	if namespaceScoped(instance) {
+		r.setCondition(instance, metav1.Condition{
+			Type:    operatorv1.ConditionDefaultIssuersReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  "NamespaceScoped",
+			Message: "ClusterIssuers are unavailable while cert-manager-controller is namespace scoped",
+		})
+		return true, nil
+	}
+
+	name := spec.Name
+	if name == "" {
+		name = defaultIssuerName
+	}
+	selfSignedName := name + "-selfsigned"
+	caName := name + "-ca"
+	resourceNS := instance.Spec.ResourceNS
+	if resourceNS == "" {
+		resourceNS = res.DeployNamespace
+	}
+
+	status := &operatorv1.DefaultIssuersStatus{}
+	var err error
+	status.SelfSignedIssuer, err = r.defaultClusterIssuer(instance, selfSignedName, certmanagerv1.IssuerSpec{
+		IssuerConfig: certmanagerv1.IssuerConfig{SelfSigned: &certmanagerv1.SelfSignedIssuer{}},
+	})
+	if err != nil {
+		return false, err
+	}
+	status.CACertificate, err = r.defaultCACertificate(instance, spec, caName, resourceNS, selfSignedName)
+	if err != nil {
+		return false, err
+	}
+	status.CAIssuer, err = r.defaultClusterIssuer(instance, name, certmanagerv1.IssuerSpec{
+		IssuerConfig: certmanagerv1.IssuerConfig{CA: &certmanagerv1.CAIssuer{SecretName: caName}},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if !equality.Semantic.DeepEqual(instance.Status.DefaultIssuers, status) {
+		instance.Status.DefaultIssuers = status
+		if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+			logd.Error(err, "Error updating instance status")
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:    operatorv1.ConditionDefaultIssuersReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Ready",
+		Message: fmt.Sprintf("ClusterIssuer %s is ready", name),
+	}
+	links := []struct {
+		kind   string
+		status operatorv1.DefaultIssuerStatus
+	}{
+		{"ClusterIssuer", status.SelfSignedIssuer},
+		{"Certificate", status.CACertificate},
+		{"ClusterIssuer", status.CAIssuer},
+	}
+	for _, link := range links {
+		if !link.status.Ready {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = link.kind + "NotReady"
+			condition.Message = fmt.Sprintf("%s %s is not ready: %s", link.kind, link.status.Name, link.status.Message)
+			break
+		}
+	}
+	r.setCondition(instance, condition)
+	return condition.Status == metav1.ConditionTrue, nil
+}
+
+// defaultClusterIssuer creates or updates a ClusterIssuer of the chain and
+// returns its readiness. ClusterIssuers are handled as unstructured objects,
+// the operator has no typed ClusterIssuer
+func (r *CertManagerReconciler) defaultClusterIssuer(instance *operatorv1.CertManagerConfig, name string, issuerSpec certmanagerv1.IssuerSpec) (operatorv1.DefaultIssuerStatus, error) {
+	status := operatorv1.DefaultIssuerStatus{Name: name}
+	spec, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&issuerSpec)
+	if err != nil {
+		return status, err
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(clusterIssuerGVK)
+	// read from API server directly, ClusterIssuers are not cached
+	err = r.Reader.Get(context.TODO(), types.NamespacedName{Name: name}, existing)
+	if apiErrors.IsNotFound(err) {
+		desired := &unstructured.Unstructured{}
+		desired.SetGroupVersionKind(clusterIssuerGVK)
+		desired.SetName(name)
+		desired.SetLabels(map[string]string{res.DefaultIssuerLabel: "true"})
+		if err := unstructured.SetNestedMap(desired.Object, spec, "spec"); err != nil {
+			return status, err
+		}
+		if err := controllerutil.SetControllerReference(instance, desired, r.Scheme); err != nil {
+			logd.Error(err, "Error setting controller reference on ClusterIssuer "+name)
+		}
+		logd.Info("Creating default ClusterIssuer " + name)
+		status.Message = "created"
+		return status, r.Client.Create(context.TODO(), desired)
+	} else if err != nil {
+		return status, err
+	}
+
+	existingSpec, _, _ := unstructured.NestedMap(existing.Object, "spec")
+	if !equality.Semantic.DeepEqual(existingSpec, spec) {
+		recordDriftCorrection("ClusterIssuer")
+		logd.Info("Updating default ClusterIssuer " + name)
+		if err := unstructured.SetNestedMap(existing.Object, spec, "spec"); err != nil {
+			return status, err
+		}
+		status.Message = "updated"
+		return status, r.Client.Update(context.TODO(), existing)
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(existing.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != string(certmanagerv1.IssuerConditionReady) {
+			continue
+		}
+		status.Ready = condition["status"] == string(cmmeta.ConditionTrue)
+		status.Message, _ = condition["message"].(string)
+	}
+	return status, nil
+}
+
+// defaultCACertificate creates or updates the CA Certificate of the chain,
+// issued by the self-signed ClusterIssuer, and returns its readiness
+func (r *CertManagerReconciler) defaultCACertificate(instance *operatorv1.CertManagerConfig, spec *operatorv1.DefaultIssuersSpec,
+	name, ns, issuerName string) (operatorv1.DefaultIssuerStatus, error) {
+	status := operatorv1.DefaultIssuerStatus{Name: name}
+
+	algorithm := certmanagerv1.ECDSAKeyAlgorithm
+	if spec.KeyAlgorithm != "" {
+		algorithm = certmanagerv1.PrivateKeyAlgorithm(spec.KeyAlgorithm)
+	}
+	size := spec.KeySize
+	if size == 0 {
+		size = defaultECDSAKeySize
+		if algorithm == certmanagerv1.RSAKeyAlgorithm {
+			size = defaultRSAKeySize
+		}
+	}
+	desiredSpec := certmanagerv1.CertificateSpec{
+		IsCA:        true,
+		CommonName:  name,
+		SecretName:  name,
+		Duration:    &metav1.Duration{Duration: durationOrDefault(spec.Duration, defaultIssuerCADuration)},
+		RenewBefore: &metav1.Duration{Duration: durationOrDefault(spec.RenewBefore, defaultIssuerRenewBefore)},
+		PrivateKey: &certmanagerv1.CertificatePrivateKey{
+			Algorithm: algorithm,
+			Size:      size,
+		},
+		IssuerRef: cmmeta.ObjectReference{
+			Name:  issuerName,
+			Kind:  "ClusterIssuer",
+			Group: certmanagerv1.GroupVersion.Group,
+		},
+	}
+
+	existing := &certmanagerv1.Certificate{}
+	err := r.Client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: ns}, existing)
+	if apiErrors.IsNotFound(err) {
+		desired := &certmanagerv1.Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels:    map[string]string{res.DefaultIssuerLabel: "true"},
+			},
+			Spec: desiredSpec,
+		}
+		if err := controllerutil.SetControllerReference(instance, desired, r.Scheme); err != nil {
+			logd.Error(err, "Error setting controller reference on Certificate "+name)
+		}
+		logd.Info("Creating default CA Certificate " + ns + "/" + name)
+		status.Message = "created"
+		return status, r.Client.Create(context.TODO(), desired)
+	} else if err != nil {
+		return status, err
+	}
+
+	if !equality.Semantic.DeepEqual(existing.Spec, desiredSpec) {
+		recordDriftCorrection("Certificate")
+		logd.Info("Updating default CA Certificate " + ns + "/" + name)
+		updated := existing.DeepCopy()
+		updated.Spec = desiredSpec
+		status.Message = "updated"
+		return status, r.Client.Update(context.TODO(), updated)
+	}
+
+	for _, c := range existing.Status.Conditions {
+		if c.Type == certmanagerv1.CertificateConditionReady {
+			status.Ready = c.Status == cmmeta.ConditionTrue
+			status.Message = c.Message
+		}
+	}
+	return status, nil
+}
+
+// removeDefaultIssuers deletes the ClusterIssuers and the Certificate of the
+// chain of default issuers. The CA secret is kept, so that the certificates it
+// signed can still be verified
+func (r *CertManagerReconciler) removeDefaultIssuers() error {
+	issuers := &unstructured.UnstructuredList{}
+	issuers.SetGroupVersionKind(clusterIssuerListGVK)
+	if err := r.Reader.List(context.TODO(), issuers, client.MatchingLabels{res.DefaultIssuerLabel: "true"}); err != nil && !apiErrors.IsNotFound(err) {
+		return err
+	}
+	for i := range issuers.Items {
+		logd.Info("Removing default ClusterIssuer " + issuers.Items[i].GetName())
+		if err := r.Client.Delete(context.TODO(), &issuers.Items[i]); err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	certificates := &certmanagerv1.CertificateList{}
+	if err := r.Client.List(context.TODO(), certificates, client.MatchingLabels{res.DefaultIssuerLabel: "true"}); err != nil {
+		return err
+	}
+	for i := range certificates.Items {
+		cert := &certificates.Items[i]
+		logd.Info("Removing default CA Certificate " + cert.Namespace + "/" + cert.Name)
+		if err := r.Client.Delete(context.TODO(), cert); err != nil && !apiErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -65,6 +65,7 @@ func deployLogic(instance *operatorv1.CertManagerConfig, client client.Client, k
 			errMsg := fmt.Sprintf("The service %s is already deployed as %s/%s. Please remove it if you want this version of %s to be deployed.",
 				name, deploy.Namespace, deploy.Name, name)
 			logd.V(4).Info(errMsg)
+			conflictDetectionsTotal.WithLabelValues(name).Inc()
 			err := errors.New(errMsg)
 			return err
 		}
@@ -82,10 +83,12 @@ func deployLogic(instance *operatorv1.CertManagerConfig, client client.Client, k
 			return err
 		}
 	} else {
+		preserveAutoscaledFields(instance, &deployment, existingDeploy)
 		if !equalDeploys(deployment, existingDeploy) {
 			// Update
 			logd.V(2).Info("Updating deployment")
 			deployment.SetResourceVersion(existingDeploy.GetResourceVersion())
+			recordDriftCorrection("Deployment")
 			if err := client.Update(context.Background(), &deployment); err != nil {
 				return err
 			}
@@ -124,16 +127,11 @@ func setupDeploy(instance *operatorv1.CertManagerConfig, deploy *appsv1.Deployme
 		var args = make([]string, len(res.DefaultArgs))
 		copy(args, res.DefaultArgs)
 		args = append(args, acmesolver, resourceNS, leaderElect)
-		returningDeploy.Spec.Template.Spec.Containers[0].Args = args
-		logd.V(3).Info("The args", "args", deploy.Spec.Template.Spec.Containers[0].Args)
-
-		//add resource limits and requests for controller only if present in CR else use default as defined in constants.go
-		if instance.Spec.CertManagerController.Resources.Limits != nil {
-			returningDeploy.Spec.Template.Spec.Containers[0].Resources.Limits = instance.Spec.CertManagerController.Resources.Limits
-		}
-		if instance.Spec.CertManagerController.Resources.Requests != nil {
-			returningDeploy.Spec.Template.Spec.Containers[0].Resources.Requests = instance.Spec.CertManagerController.Resources.Requests
+		if namespaceScoped(instance) {
+			args = append(args, res.NamespaceArg+instance.Spec.WatchNamespaces[0])
 		}
+		returningDeploy.Spec.Template.Spec.Containers[0].Args = logArgs(args, instance.Spec.CertManagerController)
+		logd.V(3).Info("The args", "args", deploy.Spec.Template.Spec.Containers[0].Args)
 
 	case res.CertManagerCainjectorName:
 		returningDeploy.Spec.Template.Spec.Containers[0].Image = res.GetImageID(imageRegistry, res.CainjectorImageName, res.ControllerImageVersion, instance.Spec.ImagePostFix, res.CaInjectorImageEnvVar)
@@ -141,57 +139,141 @@ func setupDeploy(instance *operatorv1.CertManagerConfig, deploy *appsv1.Deployme
 		var args = make([]string, len(res.DefaultArgs))
 		copy(args, res.DefaultArgs)
 		args = append(args, leaderElect)
-		returningDeploy.Spec.Template.Spec.Containers[0].Args = args
-		//add resource limits and requests for cainjector only if present in CR else use default as defined in constants.go
-		if instance.Spec.CertManagerCAInjector.Resources.Limits != nil {
-			returningDeploy.Spec.Template.Spec.Containers[0].Resources.Limits = instance.Spec.CertManagerCAInjector.Resources.Limits
-		}
-		if instance.Spec.CertManagerCAInjector.Resources.Requests != nil {
-			returningDeploy.Spec.Template.Spec.Containers[0].Resources.Requests = instance.Spec.CertManagerCAInjector.Resources.Requests
-		}
+		returningDeploy.Spec.Template.Spec.Containers[0].Args = logArgs(args, instance.Spec.CertManagerCAInjector)
 
 	case res.CertManagerWebhookName:
 		returningDeploy.Spec.Template.Spec.Containers[0].Image = res.GetImageID(imageRegistry, res.WebhookImageName, res.WebhookImageVersion, instance.Spec.ImagePostFix, res.WebhookImageEnvVar)
 		returningDeploy.Spec.Template.Spec.Containers[0].SecurityContext.ReadOnlyRootFilesystem = &res.TrueVar
+		// the container is shared with the template, so the volume mounts are
+		// set in both modes
+		if webhookTLSEnabled(instance) {
+			returningDeploy.Spec.Template.Spec.Containers[0].Args = logArgs(res.WebhookOperatorTLSArgs, instance.Spec.CertManagerWebhook)
+			returningDeploy.Spec.Template.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{
+				{Name: res.WebhookTLSVolumeName, MountPath: res.WebhookTLSMountPath, ReadOnly: true},
+			}
+			returningDeploy.Spec.Template.Spec.Volumes = []corev1.Volume{
+				{Name: res.WebhookTLSVolumeName, VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: res.WebhookServingSecret}}},
+			}
+		} else {
+			returningDeploy.Spec.Template.Spec.Containers[0].Args = logArgs(res.WebhookDefaultArgs, instance.Spec.CertManagerWebhook)
+			returningDeploy.Spec.Template.Spec.Containers[0].VolumeMounts = nil
+			returningDeploy.Spec.Template.Spec.Volumes = nil
+		}
 		if instance.Spec.DisableHostNetwork == nil {
 			returningDeploy.Spec.Template.Spec.HostNetwork = res.FalseVar //default value
 		} else {
 			returningDeploy.Spec.Template.Spec.HostNetwork = !(*instance.Spec.DisableHostNetwork)
 		}
-		//add resource limits and requests for webhook only if present in CR else use default as defined in constants.go
-		if instance.Spec.CertManagerWebhook.Resources.Limits != nil {
-			returningDeploy.Spec.Template.Spec.Containers[0].Resources.Limits = instance.Spec.CertManagerWebhook.Resources.Limits
-		}
-		if instance.Spec.CertManagerWebhook.Resources.Requests != nil {
-			returningDeploy.Spec.Template.Spec.Containers[0].Resources.Requests = instance.Spec.CertManagerWebhook.Resources.Requests
-		}
+	}
+
+	// add resource limits and requests from the profile, overridden by the
+	// ones present in the CR, else use default as defined in containers.go
+	returningDeploy.Spec.Template.Spec.Containers[0].Resources = operandResources(instance, deploy.Name)
+
+	spec := componentSpec(instance, deploy.Name)
+	returningDeploy.Spec.Strategy = deployStrategy(spec, returningDeploy.Spec.Template.Spec.HostNetwork)
+	if spec.RevisionHistoryLimit != nil {
+		returningDeploy.Spec.RevisionHistoryLimit = spec.RevisionHistoryLimit
 	}
 
 	returningDeploy.Namespace = ns
 	logd.V(2).Info("Resulting image registry", "full name", returningDeploy.Spec.Template.Spec.Containers[0].Image)
 	logd.V(3).Info("Resulting deployment to be created", "spec", fmt.Sprintf("%v", returningDeploy))
 
-	if instance.Spec.EnableInstanaMetricCollection {
-		e := corev1.EnvVar{
-			Name: "INSTANA_AGENT_HOST",
-			ValueFrom: &corev1.EnvVarSource{
-				FieldRef: &corev1.ObjectFieldSelector{
-					APIVersion: "v1",
-					FieldPath:  "status.hostIP",
-				},
-			},
-		}
-		isInstanaEnv := false
-		for i := range returningDeploy.Spec.Template.Spec.Containers[0].Env {
-			if returningDeploy.Spec.Template.Spec.Containers[0].Env[i].Name == "INSTANA_AGENT_HOST" {
-				isInstanaEnv = true
-			}
+	// the container is shared with the template, so build a new env rather
+	// than appending to the one of the template
+	container := &returningDeploy.Spec.Template.Spec.Containers[0]
+	env := make([]corev1.EnvVar, 0, len(container.Env))
+	env = append(env, container.Env...)
+	container.Env = append(env, observabilityEnv(instance, deploy.Name)...)
+	return returningDeploy
+}
+
+// componentSpec returns the spec in the CR for the operand with the given name
+func componentSpec(instance *operatorv1.CertManagerConfig, name string) operatorv1.CertManagerContainerSpec {
+	switch name {
+	case res.CertManagerControllerName:
+		return instance.Spec.CertManagerController
+	case res.CertManagerCainjectorName:
+		return instance.Spec.CertManagerCAInjector
+	case res.CertManagerWebhookName:
+		return instance.Spec.CertManagerWebhook
+	}
+	return operatorv1.CertManagerContainerSpec{}
+}
+
+// operandResources returns the resource requirements of an operand: the preset
+// of the profile set in the CR, or the operator defaults, with the limits and
+// requests set explicitly for the component taking precedence
+func operandResources(instance *operatorv1.CertManagerConfig, name string) corev1.ResourceRequirements {
+	resources := *res.DefaultResources.DeepCopy()
+	if preset, ok := res.ProfileResources[instance.Spec.Profile][name]; ok {
+		resources = *preset.DeepCopy()
+	}
+	spec := componentSpec(instance, name)
+	if spec.Resources.Limits != nil {
+		resources.Limits = spec.Resources.Limits.DeepCopy()
+	}
+	if spec.Resources.Requests != nil {
+		resources.Requests = spec.Resources.Requests.DeepCopy()
+	}
+	return resources
+}
+
+// deployStrategy returns the rollout strategy for an operand. Fields left
+// empty in the CR are filled in with the defaults, so that the result matches
+// what the API server stores and does not cause an update on every reconcile
+func deployStrategy(spec operatorv1.CertManagerContainerSpec, hostNetwork bool) appsv1.DeploymentStrategy {
+	defaults := res.DefaultDeploymentStrategy
+	if hostNetwork {
+		defaults = res.HostNetworkDeploymentStrategy
+	}
+	if spec.Strategy == nil {
+		return *defaults.DeepCopy()
+	}
+
+	strategy := *spec.Strategy.DeepCopy()
+	if strategy.Type == "" {
+		strategy.Type = appsv1.RollingUpdateDeploymentStrategyType
+	}
+	if strategy.Type == appsv1.RecreateDeploymentStrategyType {
+		strategy.RollingUpdate = nil
+		return strategy
+	}
+	if strategy.RollingUpdate == nil {
+		strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{}
+	}
+	if strategy.RollingUpdate.MaxUnavailable == nil {
+		maxUnavailable := *defaults.RollingUpdate.MaxUnavailable
+		strategy.RollingUpdate.MaxUnavailable = &maxUnavailable
+	}
+	if strategy.RollingUpdate.MaxSurge == nil {
+		maxSurge := *defaults.RollingUpdate.MaxSurge
+		strategy.RollingUpdate.MaxSurge = &maxSurge
+	}
+	return strategy
+}
+
+// logArgs returns a copy of args with the log verbosity and log format args
+// replaced by the ones set in the component spec, if any
+func logArgs(args []string, spec operatorv1.CertManagerContainerSpec) []string {
+	var result = make([]string, 0, len(args)+2)
+	for _, arg := range args {
+		if spec.LogLevel != nil && strings.HasPrefix(arg, res.LogLevelArg) {
+			continue
 		}
-		if !isInstanaEnv {
-			returningDeploy.Spec.Template.Spec.Containers[0].Env = append(returningDeploy.Spec.Template.Spec.Containers[0].Env, e)
+		if spec.LogFormat != "" && strings.HasPrefix(arg, res.LogFormatArg) {
+			continue
 		}
+		result = append(result, arg)
 	}
-	return returningDeploy
+	if spec.LogLevel != nil {
+		result = append(result, fmt.Sprintf("%s%d", res.LogLevelArg, *spec.LogLevel))
+	}
+	if spec.LogFormat != "" {
+		result = append(result, res.LogFormatArg+spec.LogFormat)
+	}
+	return result
 }
 
 func removeDeploy(client kubernetes.Interface, name, namespace string) error {
@@ -273,6 +355,20 @@ func equalDeploys(first, second appsv1.Deployment) bool {
 		return false
 	}
 
+	if !reflect.DeepEqual(first.Spec.RevisionHistoryLimit, second.Spec.RevisionHistoryLimit) {
+		statusLog.Info("Revision history limits not equal",
+			"first", fmt.Sprintf("%v", first.Spec.RevisionHistoryLimit),
+			"second", fmt.Sprintf("%v", second.Spec.RevisionHistoryLimit))
+		return false
+	}
+
+	if !reflect.DeepEqual(first.Spec.Strategy, second.Spec.Strategy) {
+		statusLog.Info("Deployment strategies not equal",
+			"first", fmt.Sprintf("%v", first.Spec.Strategy),
+			"second", fmt.Sprintf("%v", second.Spec.Strategy))
+		return false
+	}
+
 	firstPodTemplate := first.Spec.Template
 	secondPodTemplate := second.Spec.Template
 	if !reflect.DeepEqual(firstPodTemplate.ObjectMeta.Labels, secondPodTemplate.ObjectMeta.Labels) {
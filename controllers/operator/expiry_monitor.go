@@ -0,0 +1,330 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanagerv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/cert-manager/v1"
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+)
+
+const (
+	expiryScanInterval = 5 * time.Minute
+
+	defaultExpiryWarningThreshold  = 30 * 24 * time.Hour
+	defaultExpiryCriticalThreshold = 7 * 24 * time.Hour
+	defaultRenewalGracePeriod      = time.Hour
+	defaultFailedIssuanceAttempts  = 3
+
+	// certificateNameAnnotation is set by cert-manager on the secrets of
+	// Certificates, which are monitored through their Certificate
+	certificateNameAnnotation = "cert-manager.io/certificate-name"
+)
+
+// expiry states of a certificate, from the least to the most severe
+const (
+	expiryOK       = "ok"
+	expiryWarning  = "warning"
+	expiryCritical = "critical"
+	expiryExpired  = "expired"
+)
+
+// certificateState is the state of a certificate at the last scan. Events
+// are only emitted when it changes
+type certificateState struct {
+	expiry          string
+	renewalOverdue  bool
+	issuanceFailing bool
+}
+
+// expirySettings are the thresholds of spec.expiryMonitoring with their
+// defaults
+type expirySettings struct {
+	warning        time.Duration
+	critical       time.Duration
+	grace          time.Duration
+	failedAttempts int
+}
+
+// ExpiryMonitor periodically scans the Certificates and the TLS secrets not
+// managed by cert-manager. It emits Warning events when they expire, are about
+// to, are overdue for renewal or keep failing issuance, exports their expiry
+// as metrics and summarizes them in the status of the CertManagerConfig
+type ExpiryMonitor struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+	// TLSSecrets is the cache of the TLS secrets of the cluster
+	TLSSecrets client.Reader
+
+	states map[string]certificateState
+}
+
+// SetupWithManager adds the monitor to the Manager, it runs on the leader
+func (m *ExpiryMonitor) SetupWithManager(mgr ctrl.Manager) error {
+	m.states = make(map[string]certificateState)
+	return mgr.Add(m)
+}
+
+// NeedLeaderElection makes the monitor run on the leader only, so that events
+// are not emitted by every replica
+func (m *ExpiryMonitor) NeedLeaderElection() bool {
+	return true
+}
+
+// Start scans the certificates until the context is cancelled
+func (m *ExpiryMonitor) Start(ctx context.Context) error {
+	ticker := time.NewTicker(expiryScanInterval)
+	defer ticker.Stop()
+	for {
+		if err := m.scan(ctx); err != nil {
+			logd.Error(err, "Error scanning the expiry of certificates")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan checks every certificate once
+func (m *ExpiryMonitor) scan(ctx context.Context) error {
+	instances := &operatorv1.CertManagerConfigList{}
+	if err := m.Client.List(ctx, instances); err != nil {
+		return err
+	}
+	if len(instances.Items) == 0 || (instances.Items[0].Spec.ExpiryMonitoring != nil && instances.Items[0].Spec.ExpiryMonitoring.Disabled) {
+		certificateNotAfter.Reset()
+		certificatesByState.Reset()
+		m.states = make(map[string]certificateState)
+		return nil
+	}
+	settings := expiryMonitoringSettings(instances.Items[0].Spec.ExpiryMonitoring)
+
+	now := time.Now()
+	summary := &operatorv1.CertificateExpirySummary{LastScan: metav1.NewTime(now)}
+	seen := make(map[string]bool)
+	certificateNotAfter.Reset()
+
+	certificates := &certmanagerv1.CertificateList{}
+	if err := m.Client.List(ctx, certificates); err != nil {
+		return err
+	}
+	for i := range certificates.Items {
+		cert := &certificates.Items[i]
+		state := certificateState{expiry: expiryOK}
+		if cert.Status.NotAfter != nil {
+			state.expiry = settings.expiry(cert.Status.NotAfter.Time, now)
+			m.observe(summary, "Certificate", cert.Namespace, cert.Name, cert.Status.NotAfter.Time)
+		}
+		if cert.Status.RenewalTime != nil && now.After(cert.Status.RenewalTime.Add(settings.grace)) {
+			state.renewalOverdue = true
+		}
+		if cert.Status.FailedIssuanceAttempts != nil && *cert.Status.FailedIssuanceAttempts >= settings.failedAttempts {
+			state.issuanceFailing = true
+		}
+		key := "Certificate/" + cert.Namespace + "/" + cert.Name
+		seen[key] = true
+		m.report(cert, key, state, cert.Status.NotAfter)
+		count(summary, state)
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := m.TLSSecrets.List(ctx, secrets); err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if _, ok := secret.Annotations[certificateNameAnnotation]; ok {
+			continue
+		}
+		parsed := parseLeaf(secret.Data[corev1.TLSCertKey])
+		if parsed == nil {
+			continue
+		}
+		notAfter := parsed.NotAfter
+		state := certificateState{expiry: settings.expiry(notAfter, now)}
+		m.observe(summary, "Secret", secret.Namespace, secret.Name, notAfter)
+		key := "Secret/" + secret.Namespace + "/" + secret.Name
+		seen[key] = true
+		t := metav1.NewTime(notAfter)
+		m.report(secret, key, state, &t)
+		count(summary, state)
+	}
+
+	for key := range m.states {
+		if !seen[key] {
+			delete(m.states, key)
+		}
+	}
+
+	certificatesByState.Reset()
+	certificatesByState.WithLabelValues(expiryExpired).Set(float64(summary.Expired))
+	certificatesByState.WithLabelValues(expiryCritical).Set(float64(summary.Critical))
+	certificatesByState.WithLabelValues(expiryWarning).Set(float64(summary.Warning))
+	certificatesByState.WithLabelValues("renewal_overdue").Set(float64(summary.RenewalOverdue))
+	certificatesByState.WithLabelValues("issuance_failing").Set(float64(summary.IssuanceFailing))
+
+	for i := range instances.Items {
+		m.updateStatus(ctx, instances.Items[i].Name, summary)
+	}
+	return nil
+}
+
+// expiryMonitoringSettings returns the thresholds of the spec, defaulting the
+// ones not set
+func expiryMonitoringSettings(spec *operatorv1.ExpiryMonitoringSpec) expirySettings {
+	settings := expirySettings{
+		warning:        defaultExpiryWarningThreshold,
+		critical:       defaultExpiryCriticalThreshold,
+		grace:          defaultRenewalGracePeriod,
+		failedAttempts: defaultFailedIssuanceAttempts,
+	}
+	if spec == nil {
+		return settings
+	}
+	if spec.WarningThreshold != nil {
+		settings.warning = spec.WarningThreshold.Duration
+	}
+	if spec.CriticalThreshold != nil {
+		settings.critical = spec.CriticalThreshold.Duration
+	}
+	if spec.RenewalGracePeriod != nil {
+		settings.grace = spec.RenewalGracePeriod.Duration
+	}
+	if spec.FailedIssuanceAttempts != nil {
+		settings.failedAttempts = int(*spec.FailedIssuanceAttempts)
+	}
+	return settings
+}
+
+// expiry returns the expiry state of a certificate expiring at notAfter
+func (s expirySettings) expiry(notAfter, now time.Time) string {
+	switch remaining := notAfter.Sub(now); {
+	case remaining <= 0:
+		return expiryExpired
+	case remaining <= s.critical:
+		return expiryCritical
+	case remaining <= s.warning:
+		return expiryWarning
+	}
+	return expiryOK
+}
+
+// observe exports the expiry of a certificate and tracks the one expiring
+// first
+func (m *ExpiryMonitor) observe(summary *operatorv1.CertificateExpirySummary, kind, namespace, name string, notAfter time.Time) {
+	certificateNotAfter.WithLabelValues(kind, namespace, name).Set(float64(notAfter.Unix()))
+	if summary.NextExpiry == nil || notAfter.Before(summary.NextExpiry.NotAfter.Time) {
+		summary.NextExpiry = &operatorv1.ExpiringCertificate{
+			Kind:      kind,
+			Namespace: namespace,
+			Name:      name,
+			NotAfter:  metav1.NewTime(notAfter),
+		}
+	}
+}
+
+// count adds a certificate to the summary
+func count(summary *operatorv1.CertificateExpirySummary, state certificateState) {
+	summary.Total++
+	switch state.expiry {
+	case expiryExpired:
+		summary.Expired++
+	case expiryCritical:
+		summary.Critical++
+	case expiryWarning:
+		summary.Warning++
+	}
+	if state.renewalOverdue {
+		summary.RenewalOverdue++
+	}
+	if state.issuanceFailing {
+		summary.IssuanceFailing++
+	}
+}
+
+// report emits a Warning event on the object when its state gets worse
+func (m *ExpiryMonitor) report(obj client.Object, key string, state certificateState, notAfter *metav1.Time) {
+	previous, ok := m.states[key]
+	if !ok {
+		previous = certificateState{expiry: expiryOK}
+	}
+	m.states[key] = state
+
+	if state.expiry != previous.expiry {
+		switch state.expiry {
+		case expiryExpired:
+			m.Recorder.Event(obj, corev1.EventTypeWarning, "CertificateExpired",
+				fmt.Sprintf("The certificate expired at %s", notAfter.UTC().Format(time.RFC3339)))
+		case expiryCritical, expiryWarning:
+			m.Recorder.Event(obj, corev1.EventTypeWarning, "CertificateExpiringSoon",
+				fmt.Sprintf("The certificate expires at %s", notAfter.UTC().Format(time.RFC3339)))
+		}
+	}
+	if state.renewalOverdue && !previous.renewalOverdue {
+		m.Recorder.Event(obj, corev1.EventTypeWarning, "CertificateRenewalOverdue",
+			"The certificate was not renewed at its renewal time")
+	}
+	if state.issuanceFailing && !previous.issuanceFailing {
+		m.Recorder.Event(obj, corev1.EventTypeWarning, "CertificateIssuanceFailing",
+			"The issuance of the certificate keeps failing")
+	}
+}
+
+// updateStatus sets the summary in the status of a CertManagerConfig
+// sameExpirySummary returns true if the summaries only differ by the time of
+// their scan. Writing the status triggers a reconcile of the CertManagerConfig,
+// so it is only written when the summary changes
+func sameExpirySummary(current, summary *operatorv1.CertificateExpirySummary) bool {
+	if current == nil {
+		return false
+	}
+	a, b := *current, *summary
+	a.LastScan, b.LastScan = metav1.Time{}, metav1.Time{}
+	return equality.Semantic.DeepEqual(a, b)
+}
+
+func (m *ExpiryMonitor) updateStatus(ctx context.Context, name string, summary *operatorv1.CertificateExpirySummary) {
+	instance := &operatorv1.CertManagerConfig{}
+	if err := m.Client.Get(ctx, client.ObjectKey{Name: name}, instance); err != nil {
+		logd.Error(err, "Error getting instance", "name", name)
+		return
+	}
+	if sameExpirySummary(instance.Status.CertificateExpiry, summary) {
+		return
+	}
+	instance.Status.CertificateExpiry = summary.DeepCopy()
+	if err := m.Client.Status().Update(ctx, instance); err != nil {
+		if apiErrors.IsConflict(err) {
+			logd.V(1).Info("Conflict updating the certificate expiry summary, retrying at the next scan", "name", name)
+			return
+		}
+		logd.Error(err, "Error updating instance status", "name", name)
+	}
+}
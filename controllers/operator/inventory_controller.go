@@ -0,0 +1,416 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/pem"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	certmanagerv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/cert-manager/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+const (
+	// inventoryFlushInterval is how often the inventory ConfigMap is
+	// rewritten when certificates changed
+	inventoryFlushInterval = 30 * time.Second
+
+	// inventoryMaxSize keeps the ConfigMap under the 1MiB object size limit
+	inventoryMaxSize = 900 * 1024
+
+	inventoryJSONKey = "inventory.json"
+	inventoryCSVKey  = "inventory.csv"
+
+	// minimum key sizes below which a key is flagged as weak
+	minRSAKeySize   = 2048
+	minECDSAKeySize = 256
+)
+
+// flags of the inventory entries
+const (
+	inventoryWeakKey   = "WeakKey"
+	inventoryUntracked = "Untracked"
+)
+
+var inventoryCSVHeader = []string{"kind", "namespace", "name", "secretName", "issuer", "keyAlgorithm", "keySize", "sans", "notBefore", "notAfter", "flags"}
+
+// inventoryEntry describes a Certificate, or a TLS secret not managed by
+// cert-manager
+type inventoryEntry struct {
+	Kind         string       `json:"kind"`
+	Namespace    string       `json:"namespace"`
+	Name         string       `json:"name"`
+	SecretName   string       `json:"secretName,omitempty"`
+	Issuer       string       `json:"issuer,omitempty"`
+	KeyAlgorithm string       `json:"keyAlgorithm,omitempty"`
+	KeySize      int          `json:"keySize,omitempty"`
+	SANs         []string     `json:"sans,omitempty"`
+	NotBefore    *metav1.Time `json:"notBefore,omitempty"`
+	NotAfter     *metav1.Time `json:"notAfter,omitempty"`
+	Flags        []string     `json:"flags,omitempty"`
+}
+
+// InventoryReconciler maintains the ConfigMap CertificateInventoryName,
+// listing every Certificate and TLS secret of the cluster with its issuer,
+// key, SANs and validity as JSON and CSV. Entries are updated from the
+// Certificate and TLS secret informers, the ConfigMap is rewritten at most
+// every inventoryFlushInterval
+type InventoryReconciler struct {
+	Client client.Client
+	Reader client.Reader
+	NS     string
+	// TLSSecrets is the cache of the TLS secrets of the cluster
+	TLSSecrets cache.Cache
+
+	mu      sync.Mutex
+	entries map[string]inventoryEntry
+	dirty   bool
+}
+
+// Reconcile is called for a Certificate or a TLS secret of the same name, it
+// refreshes the entries of both
+func (r *InventoryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	cert := &certmanagerv1.Certificate{}
+	if err := r.Client.Get(ctx, req.NamespacedName, cert); err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		r.setEntry("Certificate/"+req.String(), nil)
+	} else {
+		secret := &corev1.Secret{}
+		if err := r.TLSSecrets.Get(ctx, types.NamespacedName{Name: cert.Spec.SecretName, Namespace: cert.Namespace}, secret); err != nil {
+			if !apiErrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+			secret = nil
+		}
+		entry := certificateEntry(cert, secret)
+		r.setEntry("Certificate/"+req.String(), &entry)
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.TLSSecrets.Get(ctx, req.NamespacedName, secret); err != nil {
+		if !apiErrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		r.setEntry("Secret/"+req.String(), nil)
+	} else if _, managed := secret.Annotations[certificateNameAnnotation]; managed {
+		// listed with its Certificate
+		r.setEntry("Secret/"+req.String(), nil)
+	} else {
+		entry := secretEntry(secret)
+		r.setEntry("Secret/"+req.String(), entry)
+	}
+	return ctrl.Result{}, nil
+}
+
+// setEntry adds, updates or, if entry is nil, removes an entry
+func (r *InventoryReconciler) setEntry(key string, entry *inventoryEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry == nil {
+		if _, ok := r.entries[key]; ok {
+			delete(r.entries, key)
+			r.dirty = true
+		}
+		return
+	}
+	r.entries[key] = *entry
+	r.dirty = true
+}
+
+// certificateEntry describes a Certificate from its spec and status, and from
+// its issued certificate if its secret exists
+func certificateEntry(cert *certmanagerv1.Certificate, secret *corev1.Secret) inventoryEntry {
+	entry := inventoryEntry{
+		Kind:         "Certificate",
+		Namespace:    cert.Namespace,
+		Name:         cert.Name,
+		SecretName:   cert.Spec.SecretName,
+		Issuer:       issuerName(cert.Spec.IssuerRef.Kind, cert.Spec.IssuerRef.Name),
+		KeyAlgorithm: string(certmanagerv1.RSAKeyAlgorithm),
+		KeySize:      minRSAKeySize,
+		NotBefore:    cert.Status.NotBefore,
+		NotAfter:     cert.Status.NotAfter,
+	}
+	if pk := cert.Spec.PrivateKey; pk != nil {
+		if pk.Algorithm != "" {
+			entry.KeyAlgorithm = string(pk.Algorithm)
+			switch pk.Algorithm {
+			case certmanagerv1.ECDSAKeyAlgorithm:
+				entry.KeySize = 256
+			case certmanagerv1.Ed25519KeyAlgorithm:
+				entry.KeySize = 0
+			}
+		}
+		if pk.Size != 0 {
+			entry.KeySize = pk.Size
+		}
+	}
+	entry.SANs = append(entry.SANs, cert.Spec.DNSNames...)
+	entry.SANs = append(entry.SANs, cert.Spec.IPAddresses...)
+	entry.SANs = append(entry.SANs, cert.Spec.URIs...)
+	entry.SANs = append(entry.SANs, cert.Spec.EmailAddresses...)
+
+	// the issued certificate is what is actually in use
+	if secret != nil {
+		if parsed := parseLeaf(secret.Data[corev1.TLSCertKey]); parsed != nil {
+			fillFromCertificate(&entry, parsed)
+		}
+	}
+	entry.Flags = keyFlags(entry)
+	return entry
+}
+
+// secretEntry describes a TLS secret not managed by cert-manager, or returns
+// nil if it holds no certificate
+func secretEntry(secret *corev1.Secret) *inventoryEntry {
+	parsed := parseLeaf(secret.Data[corev1.TLSCertKey])
+	if parsed == nil {
+		return nil
+	}
+	entry := &inventoryEntry{
+		Kind:       "Secret",
+		Namespace:  secret.Namespace,
+		Name:       secret.Name,
+		SecretName: secret.Name,
+		Issuer:     parsed.Issuer.String(),
+	}
+	fillFromCertificate(entry, parsed)
+	entry.Flags = append(keyFlags(*entry), inventoryUntracked)
+	return entry
+}
+
+// fillFromCertificate sets the key, SANs and validity of an entry from an
+// issued certificate
+func fillFromCertificate(entry *inventoryEntry, cert *x509.Certificate) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		entry.KeyAlgorithm = string(certmanagerv1.RSAKeyAlgorithm)
+		entry.KeySize = key.N.BitLen()
+	case *ecdsa.PublicKey:
+		entry.KeyAlgorithm = string(certmanagerv1.ECDSAKeyAlgorithm)
+		entry.KeySize = key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		entry.KeyAlgorithm = string(certmanagerv1.Ed25519KeyAlgorithm)
+		entry.KeySize = 0
+	}
+	var sans []string
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	entry.SANs = sans
+	notBefore, notAfter := metav1.NewTime(cert.NotBefore), metav1.NewTime(cert.NotAfter)
+	entry.NotBefore, entry.NotAfter = &notBefore, &notAfter
+}
+
+// keyFlags flags the keys smaller than the minimum size of their algorithm
+func keyFlags(entry inventoryEntry) []string {
+	switch entry.KeyAlgorithm {
+	case string(certmanagerv1.RSAKeyAlgorithm):
+		if entry.KeySize < minRSAKeySize {
+			return []string{inventoryWeakKey}
+		}
+	case string(certmanagerv1.ECDSAKeyAlgorithm):
+		if entry.KeySize < minECDSAKeySize {
+			return []string{inventoryWeakKey}
+		}
+	}
+	return nil
+}
+
+// issuerName formats an issuer reference as <kind>/<name>
+func issuerName(kind, name string) string {
+	if kind == "" {
+		kind = "Issuer"
+	}
+	return kind + "/" + name
+}
+
+// parseLeaf parses the first certificate of a PEM bundle
+func parseLeaf(data []byte) *x509.Certificate {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+	return cert
+}
+
+// flush rewrites the inventory ConfigMap every inventoryFlushInterval if the
+// entries changed, until the context is cancelled
+func (r *InventoryReconciler) flush(ctx context.Context) error {
+	ticker := time.NewTicker(inventoryFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		r.mu.Lock()
+		if !r.dirty {
+			r.mu.Unlock()
+			continue
+		}
+		entries := make([]inventoryEntry, 0, len(r.entries))
+		for _, entry := range r.entries {
+			entries = append(entries, entry)
+		}
+		r.dirty = false
+		r.mu.Unlock()
+
+		if err := r.writeInventory(ctx, entries); err != nil {
+			logd.Error(err, "Error writing the certificate inventory")
+			r.mu.Lock()
+			r.dirty = true
+			r.mu.Unlock()
+		}
+	}
+}
+
+// writeInventory creates or updates the inventory ConfigMap
+func (r *InventoryReconciler) writeInventory(ctx context.Context, entries []inventoryEntry) error {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	data, truncated, err := inventoryData(entries)
+	if err != nil {
+		return err
+	}
+	if truncated {
+		logd.Info("The certificate inventory exceeds the size of a ConfigMap, it is truncated", "entries", len(entries))
+	}
+
+	configMap := &corev1.ConfigMap{}
+	// read from API server directly, configmaps are not cached
+	err = r.Reader.Get(ctx, types.NamespacedName{Name: res.CertificateInventoryName, Namespace: r.NS}, configMap)
+	if apiErrors.IsNotFound(err) {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      res.CertificateInventoryName,
+				Namespace: r.NS,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "ibm-cert-manager-operator"},
+			},
+			Data: data,
+		}
+		return r.Client.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+	configMap.Data = data
+	return r.Client.Update(ctx, configMap)
+}
+
+// inventoryData serializes the entries as JSON and CSV, dropping the last
+// entries if they do not fit in a ConfigMap
+func inventoryData(entries []inventoryEntry) (map[string]string, bool, error) {
+	for n := len(entries); ; n = n * 9 / 10 {
+		jsonData, err := json.MarshalIndent(entries[:n], "", "  ")
+		if err != nil {
+			return nil, false, err
+		}
+		csvData := &bytes.Buffer{}
+		w := csv.NewWriter(csvData)
+		if err := w.Write(inventoryCSVHeader); err != nil {
+			return nil, false, err
+		}
+		for _, e := range entries[:n] {
+			record := []string{e.Kind, e.Namespace, e.Name, e.SecretName, e.Issuer, e.KeyAlgorithm, strconv.Itoa(e.KeySize),
+				strings.Join(e.SANs, " "), formatTime(e.NotBefore), formatTime(e.NotAfter), strings.Join(e.Flags, " ")}
+			if err := w.Write(record); err != nil {
+				return nil, false, err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, false, err
+		}
+		if len(jsonData)+csvData.Len() <= inventoryMaxSize || n == 0 {
+			return map[string]string{inventoryJSONKey: string(jsonData), inventoryCSVKey: csvData.String()}, n < len(entries), nil
+		}
+	}
+}
+
+func formatTime(t *metav1.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// SetupWithManager sets up the controller with the Manager, watching the TLS
+// secrets through TLSSecrets. It adds the flushing of the inventory on the
+// leader
+func (r *InventoryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.entries = make(map[string]inventoryEntry)
+	if err := mgr.Add(manager.RunnableFunc(r.flush)); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("inventory_controller").
+		For(&certmanagerv1.Certificate{}).
+		WatchesRawSource(source.Kind(r.TLSSecrets, &corev1.Secret{}), handler.EnqueueRequestsFromMapFunc(
+			func(ctx context.Context, obj client.Object) []reconcile.Request {
+				requests := []reconcile.Request{{NamespacedName: client.ObjectKeyFromObject(obj)}}
+				if certName, ok := obj.GetAnnotations()[certificateNameAnnotation]; ok && certName != obj.GetName() {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: certName, Namespace: obj.GetNamespace()}})
+				}
+				return requests
+			})).
+		Complete(r)
+}
@@ -0,0 +1,112 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const metricsNamespace = "ibm_cert_manager_operator"
+
+// Reconcile steps reported in the reconcile metrics
+const (
+	stepLabels  = "labels"
+	stepPrereqs = "prereqs"
+	stepCleanup = "cleanup"
+	stepDeploy  = "deploy"
+	stepWebhook = "webhook"
+	stepVersion = "version"
+	stepIssuers = "defaultissuers"
+)
+
+var (
+	reconcileStepTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_step_total",
+		Help:      "Number of reconcile steps run, by step and result.",
+	}, []string{"step", "result"})
+
+	driftCorrectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "drift_corrections_total",
+		Help:      "Number of resources updated because they drifted from the desired state, by kind.",
+	}, []string{"kind"})
+
+	operandReady = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "operand_ready",
+		Help:      "Whether all replicas of an operand deployment are ready (1) or not (0).",
+	}, []string{"operand"})
+
+	conflictDetectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "conflict_detections_total",
+		Help:      "Number of times an operand was found already deployed under another name or namespace.",
+	}, []string{"operand"})
+
+	certificateNotAfter = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "certificate_not_after_timestamp_seconds",
+		Help:      "Unix time of the expiry of a Certificate or TLS secret.",
+	}, []string{"kind", "namespace", "name"})
+
+	certificatesByState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "certificates",
+		Help:      "Number of Certificates and TLS secrets, by expiry state.",
+	}, []string{"state"})
+
+	certificatePolicyViolationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "certificate_policy_violations_total",
+		Help:      "Number of Certificates admitted or rejected in violation of a CertificatePolicy, by policy and mode.",
+	}, []string{"policy", "mode"})
+
+	lastSuccessfulReconcile = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "last_successful_reconcile_timestamp_seconds",
+		Help:      "Unix time of the last reconcile that completed without errors.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		reconcileStepTotal,
+		driftCorrectionsTotal,
+		operandReady,
+		conflictDetectionsTotal,
+		lastSuccessfulReconcile,
+		certificateNotAfter,
+		certificatesByState,
+		certificatePolicyViolationsTotal,
+	)
+}
+
+// recordReconcileStep counts the outcome of a reconcile step
+func recordReconcileStep(step string, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	reconcileStepTotal.WithLabelValues(step, result).Inc()
+}
+
+// recordDriftCorrection counts an update of a resource of the given kind
+func recordDriftCorrection(kind string) {
+	driftCorrectionsTotal.WithLabelValues(kind).Inc()
+}
@@ -0,0 +1,184 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+// monitoring reconciles the metrics Services of the operands and, when the
+// Prometheus operator is installed, their ServiceMonitors
+func monitoring(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, reader client.Reader, kubeclient kubernetes.Interface, ns string) error {
+	spec := operatorv1.MonitoringSpec{}
+	if instance.Spec.Monitoring != nil {
+		spec = *instance.Spec.Monitoring
+	}
+	services := []struct {
+		template *corev1.Service
+		enabled  bool
+	}{
+		{res.ControllerMetricsSvc, true},
+		{res.WebhookMetricsSvc, spec.Webhook && instance.Spec.Webhook},
+		{res.CainjectorMetricsSvc, spec.CAInjector && instance.Spec.Webhook},
+	}
+
+	serviceMonitors := !spec.DisableServiceMonitors && kindServed(kubeclient, res.ServiceMonitorGroupVersionKind)
+	for _, s := range services {
+		if err := metricsService(instance, scheme, client, s.template, s.enabled, ns); err != nil {
+			return err
+		}
+		if serviceMonitors {
+			if err := serviceMonitor(instance, scheme, client, reader, s.template, s.enabled, ns); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func metricsService(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, template *corev1.Service, enabled bool, ns string) error {
+	svc := &corev1.Service{}
+	err := client.Get(context.Background(), types.NamespacedName{Name: template.Name, Namespace: ns}, svc)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if !enabled {
+		if found {
+			logd.Info("Removing metrics Service " + template.Name)
+			if err := client.Delete(context.Background(), svc); err != nil && !apiErrors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !found {
+		desired := template.DeepCopy()
+		desired.Namespace = ns
+		if err := controllerutil.SetControllerReference(instance, desired, scheme); err != nil {
+			logd.Error(err, "Error setting controller reference on metrics service")
+		}
+		logd.Info("Creating metrics Service " + desired.Name)
+		return client.Create(context.Background(), desired)
+	}
+
+	originalService := svc.DeepCopy()
+	svc.Labels = template.Labels
+	svc.Spec.Selector = template.Spec.Selector
+	svc.Spec.Ports = template.Spec.Ports
+	svc.Spec.Type = template.Spec.Type
+	if compareService(svc, originalService) {
+		logd.Info("Updating metrics Service " + template.Name)
+		recordDriftCorrection("Service")
+		return client.Update(context.Background(), svc)
+	}
+	return nil
+}
+
+// serviceMonitor reconciles the ServiceMonitor scraping the given metrics
+// Service. The ServiceMonitor API is not part of the scheme, so the object is
+// handled as unstructured and read from the API server directly
+func serviceMonitor(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, reader client.Reader, svc *corev1.Service, enabled bool, ns string) error {
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(res.ServiceMonitorGroupVersionKind)
+	err := reader.Get(context.Background(), types.NamespacedName{Name: svc.Name, Namespace: ns}, existing)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if !enabled {
+		if found {
+			logd.Info("Removing ServiceMonitor " + svc.Name)
+			if err := client.Delete(context.Background(), existing); err != nil && !apiErrors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	interval := res.DefaultScrapeInterval
+	labels := make(map[string]string)
+	for k, v := range svc.Labels {
+		labels[k] = v
+	}
+	if instance.Spec.Monitoring != nil {
+		if instance.Spec.Monitoring.ScrapeInterval != "" {
+			interval = instance.Spec.Monitoring.ScrapeInterval
+		}
+		for k, v := range instance.Spec.Monitoring.ServiceMonitorLabels {
+			labels[k] = v
+		}
+	}
+	selector := make(map[string]interface{}, len(svc.Labels))
+	for k, v := range svc.Labels {
+		selector[k] = v
+	}
+	desiredSpec := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": selector,
+		},
+		"namespaceSelector": map[string]interface{}{
+			"matchNames": []interface{}{ns},
+		},
+		"endpoints": []interface{}{
+			map[string]interface{}{
+				"port":     res.MetricsPortName,
+				"path":     "/metrics",
+				"interval": interval,
+			},
+		},
+	}
+
+	if !found {
+		desired := &unstructured.Unstructured{}
+		desired.SetGroupVersionKind(res.ServiceMonitorGroupVersionKind)
+		desired.SetName(svc.Name)
+		desired.SetNamespace(ns)
+		desired.SetLabels(labels)
+		desired.Object["spec"] = desiredSpec
+		if err := controllerutil.SetControllerReference(instance, desired, scheme); err != nil {
+			logd.Error(err, "Error setting controller reference on service monitor")
+		}
+		logd.Info("Creating ServiceMonitor " + svc.Name)
+		return client.Create(context.Background(), desired)
+	}
+
+	if !equality.Semantic.DeepEqual(existing.GetLabels(), labels) || !equality.Semantic.DeepEqual(existing.Object["spec"], desiredSpec) {
+		existing.SetLabels(labels)
+		existing.Object["spec"] = desiredSpec
+		logd.Info("Updating ServiceMonitor " + svc.Name)
+		recordDriftCorrection("ServiceMonitor")
+		return client.Update(context.Background(), existing)
+	}
+	return nil
+}
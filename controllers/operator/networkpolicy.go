@@ -0,0 +1,143 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+// networkPolicies reconciles the NetworkPolicies of the operands, removing
+// them when they are disabled in the CR or the operand is not deployed
+func networkPolicies(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, ns string) error {
+	enabled := instance.Spec.NetworkPolicy != nil && instance.Spec.NetworkPolicy.Enabled
+
+	if err := networkPolicy(instance, scheme, client, res.ControllerNetworkPolicy, enabled, ns); err != nil {
+		return err
+	}
+	if err := networkPolicy(instance, scheme, client, res.WebhookNetworkPolicy, enabled && instance.Spec.Webhook, ns); err != nil {
+		return err
+	}
+	if err := networkPolicy(instance, scheme, client, res.CainjectorNetworkPolicy, enabled && instance.Spec.Webhook, ns); err != nil {
+		return err
+	}
+	return nil
+}
+
+func networkPolicy(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, template *networkingv1.NetworkPolicy, enabled bool, ns string) error {
+	policy := &networkingv1.NetworkPolicy{}
+	err := client.Get(context.Background(), types.NamespacedName{Name: template.Name, Namespace: ns}, policy)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if !enabled {
+		if found {
+			logd.Info("Removing NetworkPolicy " + template.Name)
+			if err := client.Delete(context.Background(), policy); err != nil && !apiErrors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	desired := desiredNetworkPolicy(instance, template)
+	desired.Namespace = ns
+	if !found {
+		if err := controllerutil.SetControllerReference(instance, desired, scheme); err != nil {
+			logd.Error(err, "Error setting controller reference on network policy")
+		}
+		logd.Info("Creating NetworkPolicy " + desired.Name)
+		return client.Create(context.Background(), desired)
+	}
+
+	originalPolicy := policy.DeepCopy()
+	policy.Labels = desired.Labels
+	policy.Spec = desired.Spec
+	if !equality.Semantic.DeepEqual(originalPolicy, policy) {
+		logd.Info("Updating NetworkPolicy " + desired.Name)
+		recordDriftCorrection("NetworkPolicy")
+		return client.Update(context.Background(), policy)
+	}
+	return nil
+}
+
+// desiredNetworkPolicy fills in the rules of a NetworkPolicy template which
+// depend on the CR
+func desiredNetworkPolicy(instance *operatorv1.CertManagerConfig, template *networkingv1.NetworkPolicy) *networkingv1.NetworkPolicy {
+	policy := template.DeepCopy()
+	spec := operatorv1.NetworkPolicySpec{}
+	if instance.Spec.NetworkPolicy != nil {
+		spec = *instance.Spec.NetworkPolicy
+	}
+
+	// every operand talks to the API server
+	apiServer := networkingv1.NetworkPolicyEgressRule{
+		Ports: res.APIServerEgressPorts,
+		To:    ipBlockPeers(spec.APIServerCIDRs),
+	}
+	policy.Spec.Egress = append(policy.Spec.Egress, apiServer)
+
+	// only cert-manager-controller talks to the issuers outside the cluster
+	if template.Name == res.CertManagerControllerName && len(spec.EgressCIDRs) > 0 {
+		policy.Spec.Egress = append(policy.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+			To: ipBlockPeers(spec.EgressCIDRs),
+		})
+	}
+
+	if spec.MetricsNamespace != "" {
+		protocol := corev1.ProtocolTCP
+		policy.Spec.Ingress = append(policy.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: &protocol, Port: &res.MetricsPort},
+			},
+			From: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"kubernetes.io/metadata.name": spec.MetricsNamespace,
+						},
+					},
+				},
+			},
+		})
+	}
+	return policy
+}
+
+func ipBlockPeers(cidrs []string) []networkingv1.NetworkPolicyPeer {
+	var peers []networkingv1.NetworkPolicyPeer
+	for _, cidr := range cidrs {
+		peers = append(peers, networkingv1.NetworkPolicyPeer{
+			IPBlock: &networkingv1.IPBlock{CIDR: cidr},
+		})
+	}
+	return peers
+}
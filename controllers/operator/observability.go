@@ -0,0 +1,118 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+// observabilitySpec returns the observability spec of the CR, falling back to
+// the instana provider when only the deprecated
+// EnableInstanaMetricCollection is set
+func observabilitySpec(instance *operatorv1.CertManagerConfig) *operatorv1.ObservabilitySpec {
+	if instance.Spec.Observability != nil {
+		return instance.Spec.Observability
+	}
+	if instance.Spec.EnableInstanaMetricCollection {
+		return &operatorv1.ObservabilitySpec{Provider: res.ProviderInstana}
+	}
+	return nil
+}
+
+// observabilityEnv returns the environment variables configuring the
+// telemetry of the operand with the given name. The address of the Instana
+// agent is injected into the operands of res.InstanaOperands, the OTEL_*
+// variables only into the operands of res.TelemetryOperands which read them
+func observabilityEnv(instance *operatorv1.CertManagerConfig, name string) []corev1.EnvVar {
+	spec := observabilitySpec(instance)
+	if spec == nil {
+		return nil
+	}
+
+	var env []corev1.EnvVar
+	endpoint := spec.Endpoint
+	agentHostSource := spec.AgentHostSource
+
+	if spec.Provider == res.ProviderInstana {
+		if agentHostSource == "" {
+			agentHostSource = res.AgentHostSourceHostIP
+		}
+		if res.InstanaOperands[name] {
+			env = append(env, fieldRefEnv(res.InstanaAgentHostEnv, res.AgentHostFieldPaths[agentHostSource]))
+		}
+		if endpoint == "" {
+			endpoint = "http://$(" + res.InstanaAgentHostEnv + "):" + res.InstanaOTLPPort
+		}
+	}
+	if !res.TelemetryOperands[name] {
+		logd.V(1).Info("The operand does not read the OTEL_* environment variables, skipping them", "operand", name)
+		return env
+	}
+	if spec.Provider != res.ProviderInstana && agentHostSource != "" {
+		env = append(env, fieldRefEnv(res.OTelAgentHostEnv, res.AgentHostFieldPaths[agentHostSource]))
+	}
+
+	env = append(env, corev1.EnvVar{Name: res.OTelServiceNameEnv, Value: name})
+	if endpoint != "" {
+		env = append(env, corev1.EnvVar{Name: res.OTelEndpointEnv, Value: endpoint})
+	}
+	if spec.Protocol != "" {
+		env = append(env, corev1.EnvVar{Name: res.OTelProtocolEnv, Value: spec.Protocol})
+	}
+	if spec.SamplingRatio != "" {
+		env = append(env,
+			corev1.EnvVar{Name: res.OTelTracesSamplerEnv, Value: res.OTelRatioSampler},
+			corev1.EnvVar{Name: res.OTelTracesSamplerArgEnv, Value: spec.SamplingRatio})
+	}
+	if len(spec.ResourceAttributes) > 0 {
+		env = append(env, corev1.EnvVar{Name: res.OTelResourceAttributesEnv, Value: resourceAttributes(spec.ResourceAttributes)})
+	}
+	return env
+}
+
+func fieldRefEnv(name, fieldPath string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				APIVersion: "v1",
+				FieldPath:  fieldPath,
+			},
+		},
+	}
+}
+
+// resourceAttributes renders the attributes in the key1=value1,key2=value2
+// format, sorted by key so that the value is stable across reconciles
+func resourceAttributes(attributes map[string]string) string {
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+attributes[k])
+	}
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,284 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+)
+
+// permissionCheckInterval is how often the permissions of the operator are
+// checked again once they were all found
+const permissionCheckInterval = 10 * time.Minute
+
+// requiredPermission is a rule the operator needs in order to reconcile
+type requiredPermission struct {
+	group    string
+	resource string
+	verbs    []string
+	// namespaced rules are checked in namespace, or in the namespace of the
+	// operator when it is empty
+	namespaced bool
+	namespace  string
+	// name restricts the rule to one object
+	name string
+}
+
+var (
+	objectVerbs = []string{"get", "list", "watch", "create", "update", "delete"}
+	readVerbs   = []string{"get", "list", "watch"}
+)
+
+// requiredPermissions are the rules used by the reconcile. Keep in sync with
+// the kubebuilder rbac markers of CertManagerReconciler
+var requiredPermissions = []requiredPermission{
+	{group: "operator.ibm.com", resource: "certmanagerconfigs", verbs: []string{"get", "list", "watch", "create", "update"}},
+	{group: "operator.ibm.com", resource: "certmanagerconfigs/status", verbs: []string{"update"}},
+	{group: "operator.ibm.com", resource: "certificatepolicies", verbs: readVerbs},
+	{group: "apps", resource: "deployments", verbs: append(objectVerbs, "patch"), namespaced: true},
+	{group: "apps", resource: "statefulsets", verbs: []string{"get", "list", "watch", "patch"}, namespaced: true},
+	{group: "apps", resource: "daemonsets", verbs: []string{"get", "list", "watch", "patch"}, namespaced: true},
+	{group: "", resource: "services", verbs: objectVerbs, namespaced: true},
+	{group: "", resource: "endpoints", verbs: []string{"get"}, namespaced: true},
+	{group: "", resource: "namespaces", verbs: readVerbs},
+	{group: "", resource: "serviceaccounts", verbs: objectVerbs, namespaced: true},
+	{group: "", resource: "secrets", verbs: objectVerbs, namespaced: true},
+	{group: "", resource: "configmaps", verbs: []string{"get", "list", "create", "update"}, namespaced: true},
+	{group: "", resource: "events", verbs: []string{"create", "patch"}, namespaced: true},
+	{group: "rbac.authorization.k8s.io", resource: "clusterroles", verbs: append(objectVerbs, "escalate", "bind")},
+	{group: "rbac.authorization.k8s.io", resource: "clusterrolebindings", verbs: objectVerbs},
+	{group: "rbac.authorization.k8s.io", resource: "roles", verbs: append(objectVerbs, "escalate", "bind")},
+	{group: "rbac.authorization.k8s.io", resource: "rolebindings", verbs: objectVerbs},
+	{group: "admissionregistration.k8s.io", resource: "mutatingwebhookconfigurations", verbs: objectVerbs},
+	{group: "admissionregistration.k8s.io", resource: "validatingwebhookconfigurations", verbs: objectVerbs},
+	{group: "apiregistration.k8s.io", resource: "apiservices", verbs: []string{"get", "patch", "delete"}},
+	{group: "apiextensions.k8s.io", resource: "customresourcedefinitions", verbs: []string{"list", "update"}},
+	{group: "autoscaling", resource: "horizontalpodautoscalers", verbs: objectVerbs, namespaced: true},
+	{group: "autoscaling.k8s.io", resource: "verticalpodautoscalers", verbs: objectVerbs, namespaced: true},
+	{group: "networking.k8s.io", resource: "networkpolicies", verbs: objectVerbs, namespaced: true},
+	{group: "monitoring.coreos.com", resource: "servicemonitors", verbs: objectVerbs, namespaced: true},
+	{group: "cert-manager.io", resource: "certificates", verbs: objectVerbs},
+	{group: "cert-manager.io", resource: "certificates/status", verbs: []string{"update"}},
+	{group: "cert-manager.io", resource: "issuers", verbs: readVerbs},
+	{group: "cert-manager.io", resource: "clusterissuers", verbs: objectVerbs},
+}
+
+// permissionCheck caches the result of the last permission check, shared
+// between reconciles
+type permissionCheck struct {
+	mu        sync.Mutex
+	lastCheck time.Time
+	missing   []string
+}
+
+// allowed runs a SelfSubjectAccessReview for one verb of a permission
+func allowed(kubeclient kubernetes.Interface, p requiredPermission, verb, ns string) (bool, error) {
+	resource, subresource, _ := strings.Cut(p.resource, "/")
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:       p.group,
+				Resource:    resource,
+				Subresource: subresource,
+				Name:        p.name,
+				Verb:        verb,
+			},
+		},
+	}
+	if p.namespaced {
+		review.Spec.ResourceAttributes.Namespace = permissionNamespace(p, ns)
+	}
+	result, err := kubeclient.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return result.Status.Allowed, nil
+}
+
+func permissionNamespace(p requiredPermission, ns string) string {
+	if p.namespace != "" {
+		return p.namespace
+	}
+	return ns
+}
+
+// missingPermissions runs a SelfSubjectAccessReview for every verb of every
+// permission and returns the missing rules, formatted as
+// "<verbs> on <resource>.<group> [named <name>] [in namespace <ns>]"
+func missingPermissions(kubeclient kubernetes.Interface, ns string, permissions []requiredPermission) ([]string, error) {
+	var missing []string
+	for _, p := range permissions {
+		var denied []string
+		for _, verb := range p.verbs {
+			ok, err := allowed(kubeclient, p, verb, ns)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				denied = append(denied, verb)
+			}
+		}
+		if len(denied) == 0 {
+			continue
+		}
+		rule := strings.Join(denied, ",") + " on " + p.resource
+		if p.group != "" {
+			rule += "." + p.group
+		}
+		if p.name != "" {
+			rule += " named " + p.name
+		}
+		if p.namespaced {
+			rule += " in namespace " + permissionNamespace(p, ns)
+		}
+		missing = append(missing, rule)
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// operandPermissions returns the rules of the operand RBAC the operator must
+// hold itself to create the ClusterRoles and Roles granting them. They are
+// only needed where the operator may not escalate, i.e. grant rules it does
+// not hold, so the rules of the ClusterRoles are left out when it has
+// escalate on clusterroles, and the rules of the Roles of a namespace when it
+// has escalate on roles in the namespace
+func operandPermissions(kubeclient kubernetes.Interface, instance *operatorv1.CertManagerConfig, ns string) ([]requiredPermission, error) {
+	rbac := desiredRbac(instance, ns)
+	var permissions []requiredPermission
+	type ruleKey struct{ group, resource, namespace, name string }
+	index := make(map[ruleKey]int)
+	add := func(rules []rbacv1.PolicyRule, namespaced bool, namespace string) {
+		for _, rule := range rules {
+			// the operands are not granted non resource URLs
+			names := rule.ResourceNames
+			if len(names) == 0 {
+				names = []string{""}
+			}
+			for _, group := range rule.APIGroups {
+				for _, resource := range rule.Resources {
+					for _, name := range names {
+						key := ruleKey{group, resource, namespace, name}
+						i, ok := index[key]
+						if !ok {
+							i = len(permissions)
+							index[key] = i
+							permissions = append(permissions, requiredPermission{group: group, resource: resource, namespaced: namespaced, namespace: namespace, name: name})
+						}
+						for _, verb := range rule.Verbs {
+							if !containsString(permissions[i].verbs, verb) {
+								permissions[i].verbs = append(permissions[i].verbs, verb)
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	escalate := func(resource, namespace string) (bool, error) {
+		p := requiredPermission{group: rbacv1.GroupName, resource: resource, namespaced: namespace != "", namespace: namespace}
+		return allowed(kubeclient, p, "escalate", ns)
+	}
+	ok, err := escalate("clusterroles", "")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		for _, r := range rbac.clusterRoles {
+			add(r.Rules, false, "")
+		}
+	}
+	escalating := make(map[string]bool)
+	for _, r := range rbac.roles {
+		ok, checked := escalating[r.Namespace]
+		if !checked {
+			if ok, err = escalate("roles", r.Namespace); err != nil {
+				return nil, err
+			}
+			escalating[r.Namespace] = ok
+		}
+		if !ok {
+			add(r.Rules, true, r.Namespace)
+		}
+	}
+	return permissions, nil
+}
+
+// logMissingPermissions checks the permissions of the operator once at
+// startup, before the CR can report them
+func (r *CertManagerReconciler) logMissingPermissions() {
+	missing, err := missingPermissions(r.Kubeclient, r.NS, requiredPermissions)
+	if err != nil {
+		logd.Error(err, "Error checking the permissions of the operator")
+		return
+	}
+	if len(missing) > 0 {
+		logd.Info("The operator is missing permissions, grant them to its service account", "rules", missing)
+	}
+}
+
+// checkPermissions reports the permissions the operator is missing in the
+// PermissionsMissing condition. The check is cached for
+// permissionCheckInterval, unless permissions were missing, in which case it
+// runs on every reconcile until they are granted
+func (r *CertManagerReconciler) checkPermissions(instance *operatorv1.CertManagerConfig) {
+	r.permissions.mu.Lock()
+	defer r.permissions.mu.Unlock()
+
+	if len(r.permissions.missing) == 0 && time.Since(r.permissions.lastCheck) < permissionCheckInterval {
+		return
+	}
+	permissions, err := operandPermissions(r.Kubeclient, instance, r.NS)
+	if err != nil {
+		logd.Error(err, "Error checking the permissions of the operator")
+		return
+	}
+	missing, err := missingPermissions(r.Kubeclient, r.NS, append(permissions, requiredPermissions...))
+	if err != nil {
+		logd.Error(err, "Error checking the permissions of the operator")
+		return
+	}
+	r.permissions.lastCheck = time.Now()
+	r.permissions.missing = missing
+
+	condition := metav1.Condition{
+		Type:    operatorv1.ConditionPermissionsMissing,
+		Status:  metav1.ConditionFalse,
+		Reason:  "AllPermissionsGranted",
+		Message: "The operator has all the permissions it needs",
+	}
+	if len(missing) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "PermissionsMissing"
+		condition.Message = fmt.Sprintf("Grant the following rules to the service account of the operator: %s", strings.Join(missing, "; "))
+		r.updateEvent(instance, condition.Message, corev1.EventTypeWarning, "PermissionsMissing")
+	}
+	r.setCondition(instance, condition)
+}
@@ -0,0 +1,109 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"reflect"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+// accessReviewClient returns a clientset answering the
+// SelfSubjectAccessReviews with allow
+func accessReviewClient(allow func(attributes *authorizationv1.ResourceAttributes) bool) *fake.Clientset {
+	kubeclient := fake.NewSimpleClientset()
+	kubeclient.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = allow(review.Spec.ResourceAttributes)
+		return true, review, nil
+	})
+	return kubeclient
+}
+
+func TestMissingPermissions(t *testing.T) {
+	permissions := []requiredPermission{
+		{group: "rbac.authorization.k8s.io", resource: "clusterroles", verbs: []string{"get", "escalate", "bind"}},
+		{group: "", resource: "secrets", verbs: []string{"get", "update"}, namespaced: true},
+		{group: "", resource: "configmaps", verbs: []string{"get"}, namespaced: true, namespace: "watched", name: "leader"},
+		{group: "apiregistration.k8s.io", resource: "apiservices", verbs: []string{"get"}},
+	}
+	kubeclient := accessReviewClient(func(a *authorizationv1.ResourceAttributes) bool {
+		return a.Verb == "get" && a.Resource != "configmaps"
+	})
+
+	missing, err := missingPermissions(kubeclient, "ibm-cert-manager", permissions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"escalate,bind on clusterroles.rbac.authorization.k8s.io",
+		"get on configmaps named leader in namespace watched",
+		"update on secrets in namespace ibm-cert-manager",
+	}
+	if !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %q, want %q", missing, want)
+	}
+}
+
+func TestOperandPermissions(t *testing.T) {
+	if err := res.LoadManifests(); err != nil {
+		t.Fatal(err)
+	}
+	instance := &operatorv1.CertManagerConfig{}
+
+	tests := []struct {
+		name     string
+		escalate bool
+		wantNone bool
+	}{
+		{name: "escalate granted", escalate: true, wantNone: true},
+		{name: "escalate missing", escalate: false, wantNone: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kubeclient := accessReviewClient(func(a *authorizationv1.ResourceAttributes) bool {
+				return a.Verb == "escalate" && tt.escalate
+			})
+			permissions, err := operandPermissions(kubeclient, instance, "ibm-cert-manager")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (len(permissions) == 0) != tt.wantNone {
+				t.Fatalf("got %d operand permissions, want none: %t", len(permissions), tt.wantNone)
+			}
+			seen := make(map[string]bool)
+			for _, p := range permissions {
+				key := p.group + "/" + p.resource + "/" + p.namespace + "/" + p.name
+				if seen[key] {
+					t.Errorf("duplicate permission %s", key)
+				}
+				seen[key] = true
+				if len(p.verbs) == 0 {
+					t.Errorf("permission %s has no verbs", key)
+				}
+			}
+		})
+	}
+}
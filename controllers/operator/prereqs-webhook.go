@@ -18,14 +18,18 @@ package operator
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	admRegv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
@@ -33,14 +37,16 @@ import (
 	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
 )
 
-func webhookPrereqs(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, reader client.Reader, ns string) error {
-	if err := removeOldSecret(client, reader, ns); err != nil {
-		return err
-	}
+// webhookPrereqs reconciles the service and the webhook configurations of
+// cert-manager-webhook. caBundle is injected into the webhook configurations
+// when the operator issues the serving certificate, otherwise it is nil and the
+// CA bundle injected by cert-manager-cainjector is kept. circuitAction relaxes
+// the webhooks while cert-manager-webhook is down
+func webhookPrereqs(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, recorder record.EventRecorder, ns string, caBundle []byte, circuitAction string) error {
 	if err := service(instance, scheme, client, ns); err != nil {
 		return err
 	}
-	if err := webhooks(instance, scheme, client); err != nil {
+	if err := webhooks(instance, scheme, client, recorder, ns, caBundle, circuitAction); err != nil {
 		return err
 	}
 	return nil
@@ -56,37 +62,134 @@ func removeWebhookPrereqs(client client.Client, ns string) error {
 	return nil
 }
 
-func removeOldSecret(client client.Client, reader client.Reader, ns string) error {
-	secret := &corev1.Secret{}
-	// read from API server directly since there is probably more overhead to
-	// set up informers and cache just for one secret
-	err := reader.Get(context.Background(), types.NamespacedName{Name: res.WebhookServingSecret, Namespace: ns}, secret)
-	if err != nil {
-		if apiErrors.IsNotFound(err) {
-			return nil
+// desiredWebhookConfigurations returns copies of the webhook configuration
+// templates with the admission settings of the CR applied. When caBundle is
+// set, it is injected and the annotation asking cert-manager-cainjector to
+// inject it is removed. When circuitAction is set, the webhooks are relaxed
+// with it
+func desiredWebhookConfigurations(instance *operatorv1.CertManagerConfig, caBundle []byte, circuitAction string) (*admRegv1.MutatingWebhookConfiguration, *admRegv1.ValidatingWebhookConfiguration, error) {
+	admission := instance.Spec.CertManagerWebhook.Admission
+	if err := validateAdmission(admission); err != nil {
+		return nil, nil, err
+	}
+
+	mutating := res.MutatingWebhook.DeepCopy()
+	validating := res.ValidatingWebhook.DeepCopy()
+	if caBundle != nil {
+		delete(mutating.Annotations, res.InjectCAFromSecretAnnotation)
+		delete(validating.Annotations, res.InjectCAFromSecretAnnotation)
+	}
+	for i := range mutating.Webhooks {
+		webhook := &mutating.Webhooks[i]
+		if caBundle != nil {
+			webhook.ClientConfig.CABundle = caBundle
 		}
-		return err
+		webhook.FailurePolicy, webhook.TimeoutSeconds = admissionPolicy(admission, webhook.FailurePolicy, webhook.TimeoutSeconds)
+		webhook.NamespaceSelector = mergeSelectors(webhook.NamespaceSelector, admissionNamespaceSelector(admission))
+		webhook.ObjectSelector = mergeSelectors(webhook.ObjectSelector, admissionObjectSelector(admission))
+		webhook.FailurePolicy, webhook.NamespaceSelector, webhook.ObjectSelector = relaxWebhook(circuitAction, webhook.FailurePolicy, webhook.NamespaceSelector, webhook.ObjectSelector)
 	}
-	if _, ok := secret.Annotations["cert-manager.io/allow-direct-injection"]; !ok {
-		if err := client.Delete(context.Background(), secret); err != nil {
-			return err
+	for i := range validating.Webhooks {
+		webhook := &validating.Webhooks[i]
+		if caBundle != nil {
+			webhook.ClientConfig.CABundle = caBundle
 		}
+		webhook.FailurePolicy, webhook.TimeoutSeconds = admissionPolicy(admission, webhook.FailurePolicy, webhook.TimeoutSeconds)
+		webhook.NamespaceSelector = mergeSelectors(webhook.NamespaceSelector, admissionNamespaceSelector(admission))
+		webhook.ObjectSelector = mergeSelectors(webhook.ObjectSelector, admissionObjectSelector(admission))
+		webhook.FailurePolicy, webhook.NamespaceSelector, webhook.ObjectSelector = relaxWebhook(circuitAction, webhook.FailurePolicy, webhook.NamespaceSelector, webhook.ObjectSelector)
 	}
+	return mutating, validating, nil
+}
 
+// validateAdmission checks the admission settings which cannot be validated
+// by the schema of the CRD
+func validateAdmission(admission *operatorv1.WebhookAdmissionSpec) error {
+	if admission == nil {
+		return nil
+	}
+	if admission.TimeoutSeconds != nil && (*admission.TimeoutSeconds < 1 || *admission.TimeoutSeconds > 30) {
+		return fmt.Errorf("invalid certManagerWebhook.admission.timeoutSeconds %d: must be between 1 and 30", *admission.TimeoutSeconds)
+	}
+	if admission.FailurePolicy != "" && admission.FailurePolicy != string(admRegv1.Fail) && admission.FailurePolicy != string(admRegv1.Ignore) {
+		return fmt.Errorf("invalid certManagerWebhook.admission.failurePolicy %s: must be Fail or Ignore", admission.FailurePolicy)
+	}
+	if _, err := metav1.LabelSelectorAsSelector(admission.NamespaceSelector); err != nil {
+		return fmt.Errorf("invalid certManagerWebhook.admission.namespaceSelector: %v", err)
+	}
+	if _, err := metav1.LabelSelectorAsSelector(admission.ObjectSelector); err != nil {
+		return fmt.Errorf("invalid certManagerWebhook.admission.objectSelector: %v", err)
+	}
 	return nil
 }
 
-func webhooks(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client) error {
+// admissionPolicy returns the failure policy and timeout of the CR, or the
+// ones of the template when they are not set
+func admissionPolicy(admission *operatorv1.WebhookAdmissionSpec, policy *admRegv1.FailurePolicyType, timeout *int32) (*admRegv1.FailurePolicyType, *int32) {
+	if admission == nil {
+		return policy, timeout
+	}
+	if admission.FailurePolicy != "" {
+		p := admRegv1.FailurePolicyType(admission.FailurePolicy)
+		policy = &p
+	}
+	if admission.TimeoutSeconds != nil {
+		t := *admission.TimeoutSeconds
+		timeout = &t
+	}
+	return policy, timeout
+}
+
+func admissionNamespaceSelector(admission *operatorv1.WebhookAdmissionSpec) *metav1.LabelSelector {
+	if admission == nil {
+		return nil
+	}
+	return admission.NamespaceSelector
+}
+
+func admissionObjectSelector(admission *operatorv1.WebhookAdmissionSpec) *metav1.LabelSelector {
+	if admission == nil {
+		return nil
+	}
+	return admission.ObjectSelector
+}
+
+// mergeSelectors returns a selector matching both selectors. It is never nil,
+// as the API server defaults empty selectors to {}
+func mergeSelectors(base, extra *metav1.LabelSelector) *metav1.LabelSelector {
+	merged := &metav1.LabelSelector{}
+	for _, selector := range []*metav1.LabelSelector{base, extra} {
+		if selector == nil {
+			continue
+		}
+		for k, v := range selector.MatchLabels {
+			if merged.MatchLabels == nil {
+				merged.MatchLabels = make(map[string]string)
+			}
+			merged.MatchLabels[k] = v
+		}
+		for _, expr := range selector.MatchExpressions {
+			merged.MatchExpressions = append(merged.MatchExpressions, *expr.DeepCopy())
+		}
+	}
+	return merged
+}
+
+func webhooks(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, recorder record.EventRecorder, ns string, caBundle []byte, circuitAction string) error {
+	desiredMutating, desiredValidating, err := desiredWebhookConfigurations(instance, caBundle, circuitAction)
+	if err != nil {
+		return err
+	}
+
 	mutating := &admRegv1.MutatingWebhookConfiguration{}
-	err := client.Get(context.Background(), types.NamespacedName{Name: res.CertManagerWebhookName, Namespace: ""}, mutating)
+	err = client.Get(context.Background(), types.NamespacedName{Name: res.CertManagerWebhookName, Namespace: ""}, mutating)
 	if err != nil {
 		if apiErrors.IsNotFound(err) {
 			// Create the mutating webhook spec
-			res.MutatingWebhook.ResourceVersion = ""
-			if err := controllerutil.SetControllerReference(instance, res.MutatingWebhook, scheme); err != nil {
+			if err := controllerutil.SetControllerReference(instance, desiredMutating, scheme); err != nil {
 				logd.Error(err, "Error setting controller reference on mutating webhook")
 			}
-			err := client.Create(context.Background(), res.MutatingWebhook)
+			err := client.Create(context.Background(), desiredMutating)
 			if err != nil {
 				return err
 			}
@@ -95,27 +198,18 @@ func webhooks(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, cl
 		}
 	} else {
 		originalmutating := mutating.DeepCopy()
-		if mutating.Labels == nil {
-			mutating.Labels = make(map[string]string)
-		}
-		if mutating.Annotations == nil {
-			mutating.Annotations = make(map[string]string)
-		}
-		mutating.Labels = res.MutatingWebhook.Labels
-		mutating.Annotations = res.MutatingWebhook.Annotations
-		mutating.Webhooks[0].Name = res.MutatingWebhook.Webhooks[0].Name
-		mutating.Webhooks[0].ClientConfig = res.MutatingWebhook.Webhooks[0].ClientConfig
-		mutating.Webhooks[0].Rules = res.MutatingWebhook.Webhooks[0].Rules
-		mutating.Webhooks[0].FailurePolicy = res.MutatingWebhook.Webhooks[0].FailurePolicy
-		mutating.Webhooks[0].SideEffects = res.MutatingWebhook.Webhooks[0].SideEffects
-		mutating.Webhooks[0].AdmissionReviewVersions = res.MutatingWebhook.Webhooks[0].AdmissionReviewVersions
-		mutating.Webhooks[0].TimeoutSeconds = res.MutatingWebhook.Webhooks[0].TimeoutSeconds
-		if compareMutatingWebhook(mutating, originalmutating) {
-			logd.Info("Updating Mutating Webhook " + res.CertManagerWebhookName)
+		mutating.Labels = desiredMutating.Labels
+		mutating.Annotations = desiredMutating.Annotations
+		var diff webhookDiff
+		mutating.Webhooks, diff = reconcileWebhooks(mutating.Webhooks, desiredMutating.Webhooks, mutatingEntry, ns, caBundle == nil)
+		if len(diff) > 0 || compareMutatingWebhook(mutating, originalmutating) {
+			logd.Info("Updating Mutating Webhook "+res.CertManagerWebhookName, "corrections", diff)
+			recordDriftCorrection("MutatingWebhookConfiguration")
 			err := client.Update(context.Background(), mutating)
 			if err != nil {
 				return err
 			}
+			webhookDriftEvent(instance, recorder, "MutatingWebhookConfiguration", diff)
 		}
 	}
 
@@ -124,11 +218,10 @@ func webhooks(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, cl
 	if err != nil {
 		if apiErrors.IsNotFound(err) {
 			// Create the validating webhook spec
-			res.ValidatingWebhook.ResourceVersion = ""
-			if err := controllerutil.SetControllerReference(instance, res.ValidatingWebhook, scheme); err != nil {
+			if err := controllerutil.SetControllerReference(instance, desiredValidating, scheme); err != nil {
 				logd.Error(err, "Error setting controller reference on validating webhook")
 			}
-			err := client.Create(context.Background(), res.ValidatingWebhook)
+			err := client.Create(context.Background(), desiredValidating)
 			if err != nil {
 				return err
 			}
@@ -137,35 +230,34 @@ func webhooks(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, cl
 		}
 	} else {
 		originalValidating := validating.DeepCopy()
-		if validating.Labels == nil {
-			validating.Labels = make(map[string]string)
-		}
-		if validating.Annotations == nil {
-			validating.Annotations = make(map[string]string)
-		}
-		validating.Labels = res.ValidatingWebhook.Labels
-		validating.Annotations = res.ValidatingWebhook.Annotations
-		validating.Webhooks[0].Name = res.ValidatingWebhook.Webhooks[0].Name
-		validating.Webhooks[0].ClientConfig = res.ValidatingWebhook.Webhooks[0].ClientConfig
-		validating.Webhooks[0].Rules = res.ValidatingWebhook.Webhooks[0].Rules
-		validating.Webhooks[0].FailurePolicy = res.ValidatingWebhook.Webhooks[0].FailurePolicy
-		validating.Webhooks[0].SideEffects = res.ValidatingWebhook.Webhooks[0].SideEffects
-		validating.Webhooks[0].AdmissionReviewVersions = res.ValidatingWebhook.Webhooks[0].AdmissionReviewVersions
-		validating.Webhooks[0].TimeoutSeconds = res.ValidatingWebhook.Webhooks[0].TimeoutSeconds
-		validating.Webhooks[0].NamespaceSelector = res.ValidatingWebhook.Webhooks[0].NamespaceSelector
-
-		if compareValidatingWebhook(validating, originalValidating) {
-			logd.Info("Updating Validating Webhook " + res.CertManagerWebhookName)
+		validating.Labels = desiredValidating.Labels
+		validating.Annotations = desiredValidating.Annotations
+		var diff webhookDiff
+		validating.Webhooks, diff = reconcileWebhooks(validating.Webhooks, desiredValidating.Webhooks, validatingEntry, ns, caBundle == nil)
+		if len(diff) > 0 || compareValidatingWebhook(validating, originalValidating) {
+			logd.Info("Updating Validating Webhook "+res.CertManagerWebhookName, "corrections", diff)
+			recordDriftCorrection("ValidatingWebhookConfiguration")
 			err := client.Update(context.Background(), validating)
 			if err != nil {
 				return err
 			}
+			webhookDriftEvent(instance, recorder, "ValidatingWebhookConfiguration", diff)
 		}
 	}
 
 	return nil
 }
 
+// webhookDriftEvent reports the corrections made to the webhooks of a webhook
+// configuration
+func webhookDriftEvent(instance *operatorv1.CertManagerConfig, recorder record.EventRecorder, kind string, diff webhookDiff) {
+	if len(diff) == 0 {
+		return
+	}
+	recorder.Event(instance, corev1.EventTypeNormal, "WebhookDriftCorrected",
+		fmt.Sprintf("%s %s: %s", kind, res.CertManagerWebhookName, strings.Join(diff, ", ")))
+}
+
 func removeWebhooks(client client.Client) error {
 	mutating := &admRegv1.MutatingWebhookConfiguration{}
 	err := client.Get(context.Background(), types.NamespacedName{Name: res.CertManagerWebhookName, Namespace: ""}, mutating)
@@ -224,6 +316,7 @@ func service(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, cli
 	svc.Spec.Type = res.WebhookSvc.Spec.Type
 	if compareService(svc, originalService) {
 		logd.Info("Updating Webhook Service " + res.CertManagerWebhookName)
+		recordDriftCorrection("Service")
 		err := client.Update(context.Background(), svc)
 		if err != nil {
 			return err
@@ -18,12 +18,16 @@ package operator
 
 import (
 	"context"
+	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
@@ -40,17 +44,18 @@ func checkRbac(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, c
 }
 
 func roles(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, ns string) error {
+	rbac := desiredRbac(instance, ns)
 
-	if clusterRoleErr := createClusterRole(instance, scheme, client); clusterRoleErr != nil {
+	if clusterRoleErr := createClusterRole(instance, scheme, client, rbac.clusterRoles); clusterRoleErr != nil {
 		return clusterRoleErr
 	}
-	if roleErr := createRole(instance, scheme, client, ns); roleErr != nil {
+	if roleErr := createRole(instance, scheme, client, rbac.roles); roleErr != nil {
 		return roleErr
 	}
-	if clusterRoleBindingErr := createClusterRoleBinding(instance, scheme, client, ns); clusterRoleBindingErr != nil {
+	if clusterRoleBindingErr := createClusterRoleBinding(instance, scheme, client, rbac.clusterRoleBindings); clusterRoleBindingErr != nil {
 		return clusterRoleBindingErr
 	}
-	if roleBindingErr := createRoleBinding(instance, scheme, client, ns); roleBindingErr != nil {
+	if roleBindingErr := createRoleBinding(instance, scheme, client, rbac.roleBindings); roleBindingErr != nil {
 		return roleBindingErr
 	}
 	if serviceAccountErr := createServiceAccount(instance, scheme, client, ns); serviceAccountErr != nil {
@@ -59,15 +64,121 @@ func roles(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, clien
 	return nil
 }
 
-func createRole(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, namespace string) error {
-	logd.V(0).Info("Creating roles")
+// rbacSet is the RBAC the operator grants to the operands
+type rbacSet struct {
+	clusterRoles        []rbacv1.ClusterRole
+	clusterRoleBindings []rbacv1.ClusterRoleBinding
+	roles               []rbacv1.Role
+	roleBindings        []rbacv1.RoleBinding
+}
+
+// desiredRbac returns the RBAC objects to create for the CR, labeled and with
+// their namespace and subjects filled in. When cert-manager-controller is
+// namespace scoped, its namespaced ClusterRoles are replaced by Roles in the
+// watched namespaces and its cluster scoped ClusterRoles are left out. The
+// aggregated user facing ClusterRoles are included unless disabled in the CR
+func desiredRbac(instance *operatorv1.CertManagerConfig, namespace string) rbacSet {
+	excluded := make(map[string]bool)
+	if namespaceScoped(instance) {
+		for _, r := range res.ControllerNamespacedClusterRoles {
+			excluded[r.Name] = true
+		}
+		for _, r := range res.ControllerClusterScopedClusterRoles {
+			excluded[r.Name] = true
+		}
+	}
+
+	clusterRoles := append([]rbacv1.ClusterRole{}, res.ClusterRolesToCreate.Items...)
+	if aggregatedClusterRolesEnabled(instance) {
+		clusterRoles = append(clusterRoles, res.AggregatedClusterRolesToCreate.Items...)
+	}
+
+	rbac := rbacSet{}
+	for _, r := range clusterRoles {
+		if excluded[r.Name] {
+			continue
+		}
+		role := r.DeepCopy()
+		role.Labels = rbacLabels(role.Labels)
+		rbac.clusterRoles = append(rbac.clusterRoles, *role)
+	}
+	for _, b := range res.ClusterRoleBindingsToCreate.Items {
+		if excluded[b.RoleRef.Name] {
+			continue
+		}
+		binding := b.DeepCopy()
+		binding.Labels = rbacLabels(binding.Labels)
+		for i := range binding.Subjects {
+			binding.Subjects[i].Namespace = namespace
+		}
+		rbac.clusterRoleBindings = append(rbac.clusterRoleBindings, *binding)
+	}
 	for _, r := range res.RolesToCreate.Items {
+		role := r.DeepCopy()
+		role.Namespace = namespace
+		role.Labels = rbacLabels(role.Labels)
+		rbac.roles = append(rbac.roles, *role)
+	}
+	for _, b := range res.RoleBindingsToCreate.Items {
+		binding := b.DeepCopy()
+		binding.Namespace = namespace
+		binding.Labels = rbacLabels(binding.Labels)
+		for i := range binding.Subjects {
+			binding.Subjects[i].Namespace = namespace
+		}
+		rbac.roleBindings = append(rbac.roleBindings, *binding)
+	}
+
+	if !namespaceScoped(instance) {
+		return rbac
+	}
+	for _, watched := range instance.Spec.WatchNamespaces {
+		for _, r := range res.ControllerNamespacedClusterRoles {
+			rbac.roles = append(rbac.roles, rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      r.Name,
+					Namespace: watched,
+					Labels:    rbacLabels(r.Labels),
+				},
+				Rules: r.DeepCopy().Rules,
+			})
+			rbac.roleBindings = append(rbac.roleBindings, rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      r.Name,
+					Namespace: watched,
+					Labels:    rbacLabels(r.Labels),
+				},
+				Subjects: []rbacv1.Subject{
+					{
+						Kind:      "ServiceAccount",
+						Name:      res.ControllerServiceAccount.Name,
+						Namespace: namespace,
+					},
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: "rbac.authorization.k8s.io",
+					Kind:     "Role",
+					Name:     r.Name,
+				},
+			})
+		}
+	}
+	return rbac
+}
+
+// aggregatedClusterRolesEnabled returns true unless the aggregated
+// ClusterRoles are disabled in the CR
+func aggregatedClusterRolesEnabled(instance *operatorv1.CertManagerConfig) bool {
+	return instance.Spec.EnableAggregatedClusterRoles == nil || *instance.Spec.EnableAggregatedClusterRoles
+}
+
+func createRole(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, roles []rbacv1.Role) error {
+	logd.V(0).Info("Creating roles")
+	for _, r := range roles {
 		logd.V(0).Info("Creating role " + r.Name)
 		role := &rbacv1.Role{}
-		err := client.Get(context.Background(), types.NamespacedName{Name: r.Name, Namespace: namespace}, role)
+		err := client.Get(context.Background(), types.NamespacedName{Name: r.Name, Namespace: r.Namespace}, role)
 		if err != nil && apiErrors.IsNotFound(err) {
-			r.ResourceVersion = ""
-			r.Namespace = namespace
 			if err := controllerutil.SetControllerReference(instance, &r, scheme); err != nil {
 				logd.Error(err, "Error setting controller reference on role")
 			}
@@ -79,8 +190,11 @@ func createRole(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme,
 			return err
 		} else {
 			oldRole := role.DeepCopy()
+			role.Labels = mergeLabels(role.Labels, r.Labels)
 			role.Rules = r.Rules
 			if !equality.Semantic.DeepEqual(oldRole, role) {
+				logd.Info("Updating role " + r.Name)
+				recordDriftCorrection("Role")
 				err := client.Update(context.Background(), role)
 				if err != nil {
 					return err
@@ -91,15 +205,13 @@ func createRole(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme,
 	return nil
 }
 
-func createClusterRole(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client) error {
+func createClusterRole(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, clusterRoles []rbacv1.ClusterRole) error {
 	logd.V(0).Info("Creating cluster roles")
-	for _, r := range res.ClusterRolesToCreate.Items {
+	for _, r := range clusterRoles {
 		logd.V(0).Info("Creating cluster role " + r.Name)
 		clusterRole := &rbacv1.ClusterRole{}
 		err := client.Get(context.Background(), types.NamespacedName{Name: r.Name, Namespace: ""}, clusterRole)
 		if err != nil && apiErrors.IsNotFound(err) {
-			r.ResourceVersion = ""
-
 			if err := controllerutil.SetControllerReference(instance, &r, scheme); err != nil {
 				logd.Error(err, "Error setting controller reference on clusterrole")
 			}
@@ -111,8 +223,11 @@ func createClusterRole(instance *operatorv1.CertManagerConfig, scheme *runtime.S
 			return err
 		} else {
 			oldClusterRole := clusterRole.DeepCopy()
+			clusterRole.Labels = mergeLabels(clusterRole.Labels, r.Labels)
 			clusterRole.Rules = r.Rules
 			if !equality.Semantic.DeepEqual(oldClusterRole, clusterRole) {
+				logd.Info("Updating cluster role " + r.Name)
+				recordDriftCorrection("ClusterRole")
 				err := client.Update(context.Background(), clusterRole)
 				if err != nil {
 					return err
@@ -123,18 +238,14 @@ func createClusterRole(instance *operatorv1.CertManagerConfig, scheme *runtime.S
 	return nil
 }
 
-func createClusterRoleBinding(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, namespace string) error {
+func createClusterRoleBinding(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, clusterRoleBindings []rbacv1.ClusterRoleBinding) error {
 	logd.V(0).Info("Creating cluster role binding")
-	for _, b := range res.ClusterRoleBindingsToCreate.Items {
+	for _, b := range clusterRoleBindings {
 		logd.V(0).Info("Creating cluster role binding " + b.Name)
 		clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
 
 		err := client.Get(context.Background(), types.NamespacedName{Name: b.Name, Namespace: ""}, clusterRoleBinding)
 		if err != nil && apiErrors.IsNotFound(err) {
-			b.ResourceVersion = ""
-			for i := range b.Subjects {
-				b.Subjects[i].Namespace = namespace
-			}
 			if err := controllerutil.SetControllerReference(instance, &b, scheme); err != nil {
 				logd.Error(err, "Error setting controller reference on clusterrolebinding")
 			}
@@ -145,13 +256,13 @@ func createClusterRoleBinding(instance *operatorv1.CertManagerConfig, scheme *ru
 		} else if err != nil {
 			return err
 		} else {
-			for i := range b.Subjects {
-				b.Subjects[i].Namespace = namespace
-			}
 			oldClusterRoleBinding := clusterRoleBinding.DeepCopy()
+			clusterRoleBinding.Labels = mergeLabels(clusterRoleBinding.Labels, b.Labels)
 			clusterRoleBinding.RoleRef = b.RoleRef
 			clusterRoleBinding.Subjects = b.Subjects
 			if !equality.Semantic.DeepEqual(oldClusterRoleBinding, clusterRoleBinding) {
+				logd.Info("Updating cluster role binding " + b.Name)
+				recordDriftCorrection("ClusterRoleBinding")
 				err := client.Update(context.Background(), clusterRoleBinding)
 				if err != nil {
 					return err
@@ -163,19 +274,14 @@ func createClusterRoleBinding(instance *operatorv1.CertManagerConfig, scheme *ru
 	return nil
 }
 
-func createRoleBinding(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, namespace string) error {
+func createRoleBinding(instance *operatorv1.CertManagerConfig, scheme *runtime.Scheme, client client.Client, roleBindings []rbacv1.RoleBinding) error {
 	logd.V(0).Info("Creating role binding")
-	for _, b := range res.RoleBindingsToCreate.Items {
+	for _, b := range roleBindings {
 		logd.V(0).Info("Creating role binding " + b.Name)
 		roleBinding := &rbacv1.RoleBinding{}
 
-		err := client.Get(context.Background(), types.NamespacedName{Name: b.Name, Namespace: namespace}, roleBinding)
+		err := client.Get(context.Background(), types.NamespacedName{Name: b.Name, Namespace: b.Namespace}, roleBinding)
 		if err != nil && apiErrors.IsNotFound(err) {
-			b.ResourceVersion = ""
-			b.Namespace = namespace
-			for i := range b.Subjects {
-				b.Subjects[i].Namespace = namespace
-			}
 			if err := controllerutil.SetControllerReference(instance, &b, scheme); err != nil {
 				logd.Error(err, "Error setting controller reference on rolebinding")
 			}
@@ -186,22 +292,18 @@ func createRoleBinding(instance *operatorv1.CertManagerConfig, scheme *runtime.S
 		} else if err != nil {
 			return err
 		} else {
-			for i := range b.Subjects {
-				b.Subjects[i].Namespace = namespace
-			}
 			oldRolebinding := roleBinding.DeepCopy()
+			roleBinding.Labels = mergeLabels(roleBinding.Labels, b.Labels)
 			roleBinding.RoleRef = b.RoleRef
 			roleBinding.Subjects = b.Subjects
 			if !equality.Semantic.DeepEqual(oldRolebinding, roleBinding) {
+				logd.Info("Updating role binding " + b.Name)
+				recordDriftCorrection("RoleBinding")
 				err := client.Update(context.Background(), roleBinding)
 				if err != nil {
 					return err
 				}
 			}
-			err := client.Update(context.Background(), roleBinding)
-			if err != nil {
-				return err
-			}
 		}
 	}
 
@@ -227,3 +329,125 @@ func createServiceAccount(instance *operatorv1.CertManagerConfig, scheme *runtim
 	}
 	return nil
 }
+
+// pruneRbac removes the operand RBAC objects, see operandRbac, which are no
+// longer part of the desired set, e.g. left over by an older version
+// of the operator or in a namespace no longer watched. An event is emitted on
+// the CR for every deletion
+func pruneRbac(instance *operatorv1.CertManagerConfig, client client.Client, recorder record.EventRecorder, namespace string) error {
+	rbac := desiredRbac(instance, namespace)
+
+	desiredClusterRoles := make(map[string]bool)
+	for _, r := range rbac.clusterRoles {
+		desiredClusterRoles[r.Name] = true
+	}
+	clusterRoles := &rbacv1.ClusterRoleList{}
+	if err := client.List(context.Background(), clusterRoles); err != nil {
+		return err
+	}
+	for i := range clusterRoles.Items {
+		if operandRbac(instance, &clusterRoles.Items[i]) && !desiredClusterRoles[clusterRoles.Items[i].Name] {
+			if err := pruneRbacObject(instance, client, recorder, &clusterRoles.Items[i], "ClusterRole"); err != nil {
+				return err
+			}
+		}
+	}
+
+	desiredClusterRoleBindings := make(map[string]bool)
+	for _, b := range rbac.clusterRoleBindings {
+		desiredClusterRoleBindings[b.Name] = true
+	}
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{}
+	if err := client.List(context.Background(), clusterRoleBindings); err != nil {
+		return err
+	}
+	for i := range clusterRoleBindings.Items {
+		if operandRbac(instance, &clusterRoleBindings.Items[i]) && !desiredClusterRoleBindings[clusterRoleBindings.Items[i].Name] {
+			if err := pruneRbacObject(instance, client, recorder, &clusterRoleBindings.Items[i], "ClusterRoleBinding"); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Roles and RoleBindings may be in any of the watched namespaces, so
+	// they are listed in all namespaces
+	desiredRoles := make(map[types.NamespacedName]bool)
+	for _, r := range rbac.roles {
+		desiredRoles[types.NamespacedName{Name: r.Name, Namespace: r.Namespace}] = true
+	}
+	roles := &rbacv1.RoleList{}
+	if err := client.List(context.Background(), roles); err != nil {
+		return err
+	}
+	for i := range roles.Items {
+		if operandRbac(instance, &roles.Items[i]) && !desiredRoles[types.NamespacedName{Name: roles.Items[i].Name, Namespace: roles.Items[i].Namespace}] {
+			if err := pruneRbacObject(instance, client, recorder, &roles.Items[i], "Role"); err != nil {
+				return err
+			}
+		}
+	}
+
+	desiredRoleBindings := make(map[types.NamespacedName]bool)
+	for _, b := range rbac.roleBindings {
+		desiredRoleBindings[types.NamespacedName{Name: b.Name, Namespace: b.Namespace}] = true
+	}
+	roleBindings := &rbacv1.RoleBindingList{}
+	if err := client.List(context.Background(), roleBindings); err != nil {
+		return err
+	}
+	for i := range roleBindings.Items {
+		if operandRbac(instance, &roleBindings.Items[i]) && !desiredRoleBindings[types.NamespacedName{Name: roleBindings.Items[i].Name, Namespace: roleBindings.Items[i].Namespace}] {
+			if err := pruneRbacObject(instance, client, recorder, &roleBindings.Items[i], "RoleBinding"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func pruneRbacObject(instance *operatorv1.CertManagerConfig, client client.Client, recorder record.EventRecorder, obj client.Object, kind string) error {
+	logd.Info("Pruning " + kind + " " + obj.GetName() + " no longer managed by the operator")
+	if err := client.Delete(context.Background(), obj); err != nil && !apiErrors.IsNotFound(err) {
+		return err
+	}
+	recorder.Event(instance, corev1.EventTypeNormal, "RBACPruned",
+		fmt.Sprintf("Removed %s %s, generation %s, which is no longer part of the operator RBAC",
+			kind, obj.GetName(), obj.GetLabels()[res.RBACGenerationLabel]))
+	return nil
+}
+
+// operandRbac returns true if the RBAC object was created by the operator for
+// the operands. The current releases mark it with the generation label, the
+// older releases set the CertManagerConfig as its controller. The RBAC of the
+// operator itself carries neither and is never pruned
+func operandRbac(instance *operatorv1.CertManagerConfig, obj client.Object) bool {
+	if _, ok := obj.GetLabels()[res.RBACGenerationLabel]; ok {
+		return true
+	}
+	return metav1.IsControlledBy(obj, instance)
+}
+
+// rbacLabels returns a copy of labels with the managed-by and generation
+// markers added
+func rbacLabels(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels)+2)
+	for k, v := range labels {
+		result[k] = v
+	}
+	result[res.RBACManagedByLabel] = res.RBACManagedByValue
+	result[res.RBACGenerationLabel] = res.RBACGeneration
+	return result
+}
+
+// mergeLabels returns a copy of existing with the desired labels set, keeping
+// the labels added by others
+func mergeLabels(existing, desired map[string]string) map[string]string {
+	result := make(map[string]string, len(existing)+len(desired))
+	for k, v := range existing {
+		result[k] = v
+	}
+	for k, v := range desired {
+		result[k] = v
+	}
+	return result
+}
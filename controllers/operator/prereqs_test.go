@@ -0,0 +1,76 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+func TestOperandRbac(t *testing.T) {
+	instance := &operatorv1.CertManagerConfig{ObjectMeta: metav1.ObjectMeta{Name: "default", UID: types.UID("instance")}}
+	owner := func(uid types.UID, controller bool) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{APIVersion: "operator.ibm.com/v1", Kind: "CertManagerConfig", Name: "default", UID: uid, Controller: &controller}}
+	}
+
+	tests := []struct {
+		name string
+		meta metav1.ObjectMeta
+		want bool
+	}{
+		{
+			name: "current operand RBAC",
+			meta: metav1.ObjectMeta{Labels: map[string]string{res.RBACGenerationLabel: res.RBACGeneration}},
+			want: true,
+		},
+		{
+			name: "RBAC of an older release controlled by the CR",
+			meta: metav1.ObjectMeta{OwnerReferences: owner("instance", true)},
+			want: true,
+		},
+		{
+			name: "RBAC of the operator",
+			meta: metav1.ObjectMeta{Labels: map[string]string{res.RBACManagedByLabel: res.RBACManagedByValue}},
+			want: false,
+		},
+		{
+			name: "owned but not controlled by the CR",
+			meta: metav1.ObjectMeta{OwnerReferences: owner("instance", false)},
+			want: false,
+		},
+		{
+			name: "controlled by another object",
+			meta: metav1.ObjectMeta{OwnerReferences: owner("other", true)},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role := &rbacv1.ClusterRole{ObjectMeta: tt.meta}
+			if got := operandRbac(instance, role); got != tt.want {
+				t.Errorf("operandRbac() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
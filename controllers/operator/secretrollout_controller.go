@@ -0,0 +1,290 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"golang.org/x/time/rate"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+const (
+	// secretRolloutInterval is the minimum time between two rollouts of a
+	// workload
+	secretRolloutInterval = 5 * time.Minute
+
+	// secretRolloutRate is the number of workloads rolled out per second,
+	// secretRolloutBurst the number rolled out at once
+	secretRolloutRate  = 1
+	secretRolloutBurst = 5
+
+	secretRolloutRequeue = 30 * time.Second
+)
+
+// SecretRolloutReconciler rolls out the Deployments, StatefulSets and
+// DaemonSets referencing a secret labeled with res.SecretWatchLabel when its
+// data changes, e.g. after the renewal of a certificate
+type SecretRolloutReconciler struct {
+	Client   client.Client
+	Recorder record.EventRecorder
+
+	limiter *rate.Limiter
+}
+
+// Reconcile is called for a watched secret, and for the secrets referenced by
+// a workload when it changes. The first hash recorded on a workload is a
+// baseline and does not roll it out
+func (r *SecretRolloutReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	workloads, err := r.workloads(ctx, req.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var requeueAfter time.Duration
+	for _, workload := range workloads {
+		template := podTemplate(workload)
+		if !containsString(referencedSecrets(template), req.Name) {
+			continue
+		}
+		hash, err := r.secretsHash(ctx, req.Namespace, referencedSecrets(template))
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if hash == "" {
+			// the workload references no watched secret, it is not
+			// annotated
+			continue
+		}
+		previous := workload.GetAnnotations()[res.SecretHashAnnotation]
+		if previous == hash {
+			continue
+		}
+
+		patch := client.MergeFrom(workload.DeepCopyObject().(client.Object))
+		annotations := workload.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[res.SecretHashAnnotation] = hash
+		workload.SetAnnotations(annotations)
+
+		rollout := previous != "" && annotations[res.SecretRolloutOptOutAnnotation] != "true"
+		if rollout {
+			if last, err := time.Parse(time.RFC3339, template.Annotations[res.SecretRolloutAnnotation]); err == nil {
+				if wait := secretRolloutInterval - time.Since(last); wait > 0 {
+					if requeueAfter == 0 || wait < requeueAfter {
+						requeueAfter = wait
+					}
+					continue
+				}
+			}
+			if !r.limiter.Allow() {
+				logd.V(1).Info("Rate limiting the rollout of workloads", "secret", req.NamespacedName)
+				return ctrl.Result{RequeueAfter: secretRolloutRequeue}, nil
+			}
+			if template.Annotations == nil {
+				template.Annotations = make(map[string]string)
+			}
+			template.Annotations[res.SecretRolloutAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		}
+
+		if err := r.Client.Patch(ctx, workload, patch); err != nil {
+			if apiErrors.IsNotFound(err) {
+				continue
+			}
+			return ctrl.Result{}, err
+		}
+		if rollout {
+			logd.Info("Rolling out workload for changed secret", "kind", workload.GetObjectKind().GroupVersionKind().Kind,
+				"workload", workload.GetNamespace()+"/"+workload.GetName(), "secret", req.Name)
+			r.Recorder.Eventf(workload, corev1.EventTypeNormal, "SecretRollout", "Rolling out, the watched secret %s changed", req.Name)
+		}
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// workloads returns the Deployments, StatefulSets and DaemonSets of the
+// namespace
+func (r *SecretRolloutReconciler) workloads(ctx context.Context, namespace string) ([]client.Object, error) {
+	var workloads []client.Object
+	deployments := &appsv1.DeploymentList{}
+	if err := r.Client.List(ctx, deployments, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		workloads = append(workloads, &deployments.Items[i])
+	}
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.Client.List(ctx, statefulSets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range statefulSets.Items {
+		workloads = append(workloads, &statefulSets.Items[i])
+	}
+	daemonSets := &appsv1.DaemonSetList{}
+	if err := r.Client.List(ctx, daemonSets, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range daemonSets.Items {
+		workloads = append(workloads, &daemonSets.Items[i])
+	}
+	return workloads, nil
+}
+
+// secretsHash returns the hash of the data of the watched secrets among the
+// given ones, or an empty string if none is watched. Only the watched secrets
+// are cached, the others are not found
+func (r *SecretRolloutReconciler) secretsHash(ctx context.Context, namespace string, names []string) (string, error) {
+	h := sha256.New()
+	watched := false
+	for _, name := range names {
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, secret); err != nil {
+			if apiErrors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+		if _, ok := secret.Labels[res.SecretWatchLabel]; !ok {
+			continue
+		}
+		watched = true
+		keys := make([]string, 0, len(secret.Data))
+		for k := range secret.Data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		h.Write([]byte(name))
+		for _, k := range keys {
+			h.Write([]byte(k))
+			h.Write(secret.Data[k])
+		}
+	}
+	if !watched {
+		return "", nil
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+func podTemplate(workload client.Object) *corev1.PodTemplateSpec {
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		return &w.Spec.Template
+	case *appsv1.StatefulSet:
+		return &w.Spec.Template
+	case *appsv1.DaemonSet:
+		return &w.Spec.Template
+	}
+	return &corev1.PodTemplateSpec{}
+}
+
+// referencedSecrets returns the sorted names of the secrets mounted or
+// referenced in the environment of the pod template
+func referencedSecrets(template *corev1.PodTemplateSpec) []string {
+	names := make(map[string]bool)
+	for _, v := range template.Spec.Volumes {
+		if v.Secret != nil {
+			names[v.Secret.SecretName] = true
+		}
+		if v.Projected != nil {
+			for _, source := range v.Projected.Sources {
+				if source.Secret != nil {
+					names[source.Secret.Name] = true
+				}
+			}
+		}
+	}
+	containers := append([]corev1.Container{}, template.Spec.InitContainers...)
+	containers = append(containers, template.Spec.Containers...)
+	for _, c := range containers {
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				names[env.ValueFrom.SecretKeyRef.Name] = true
+			}
+		}
+		for _, envFrom := range c.EnvFrom {
+			if envFrom.SecretRef != nil {
+				names[envFrom.SecretRef.Name] = true
+			}
+		}
+	}
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// enqueueReferencedSecrets maps a workload to the secrets it references, so
+// that the baseline hash is recorded on new workloads
+func enqueueReferencedSecrets(ctx context.Context, workload client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+	for _, name := range referencedSecrets(podTemplate(workload)) {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: workload.GetNamespace()}})
+	}
+	return requests
+}
+
+// WatchedSecretsSelector selects the secrets labeled with res.SecretWatchLabel.
+// The cache of the manager is restricted to them
+func WatchedSecretsSelector() labels.Selector {
+	watched, err := labels.NewRequirement(res.SecretWatchLabel, selection.Exists, nil)
+	if err != nil {
+		panic(err)
+	}
+	return labels.NewSelector().Add(*watched)
+}
+
+// SetupWithManager sets up the controller with the Manager. The cache of the
+// manager must only hold the watched secrets
+func (r *SecretRolloutReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.limiter = rate.NewLimiter(secretRolloutRate, secretRolloutBurst)
+	watched := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok := obj.GetLabels()[res.SecretWatchLabel]
+		return ok
+	})
+	// new workloads and workloads whose spec changed may reference other
+	// secrets and need a baseline hash
+	changed := predicate.GenerationChangedPredicate{}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("secretrollout_controller").
+		For(&corev1.Secret{}, builder.WithPredicates(watched)).
+		Watches(&appsv1.Deployment{}, handler.EnqueueRequestsFromMapFunc(enqueueReferencedSecrets), builder.WithPredicates(changed)).
+		Watches(&appsv1.StatefulSet{}, handler.EnqueueRequestsFromMapFunc(enqueueReferencedSecrets), builder.WithPredicates(changed)).
+		Watches(&appsv1.DaemonSet{}, handler.EnqueueRequestsFromMapFunc(enqueueReferencedSecrets), builder.WithPredicates(changed)).
+		Complete(r)
+}
@@ -0,0 +1,64 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewTLSSecretCache adds to the Manager a cache of the TLS secrets of the
+// cluster, shared by the CertRefresh and Inventory controllers and the
+// ExpiryMonitor. The manager only caches the secrets watched by the operator.
+// The cached secrets hold only their certificates, not their private keys
+func NewTLSSecretCache(mgr ctrl.Manager) (cache.Cache, error) {
+	tlsSecrets, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme: mgr.GetScheme(),
+		Mapper: mgr.GetRESTMapper(),
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.Secret{}: {
+				Field:     fields.OneTermEqualSelector("type", string(corev1.SecretTypeTLS)),
+				Transform: stripTLSSecret,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := mgr.Add(tlsSecrets); err != nil {
+		return nil, err
+	}
+	return tlsSecrets, nil
+}
+
+// stripTLSSecret keeps only tls.crt and ca.crt in the data of a cached secret
+func stripTLSSecret(obj interface{}) (interface{}, error) {
+	if secret, ok := obj.(*corev1.Secret); ok {
+		data := make(map[string][]byte)
+		for _, key := range []string{corev1.TLSCertKey, corev1.ServiceAccountRootCAKey} {
+			if value, ok := secret.Data[key]; ok {
+				data[key] = value
+			}
+		}
+		secret.Data = data
+		secret.ManagedFields = nil
+	}
+	return obj, nil
+}
@@ -25,10 +25,14 @@ import (
 
 	utilyaml "github.com/ghodss/yaml"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	"k8s.io/apimachinery/pkg/runtime/serializer/streaming"
 	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
 )
 
 func containsString(source []string, str string) bool {
@@ -109,3 +113,25 @@ func ClearLabelMap(m map[string]string) {
 		delete(m, k)
 	}
 }
+
+// kindServed returns true if the API server serves the given kind, e.g. because
+// the CRD of an optional component is installed
+func kindServed(kubeclient kubernetes.Interface, gvk schema.GroupVersionKind) bool {
+	resources, err := kubeclient.Discovery().ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	if err != nil {
+		logd.V(2).Info("API not found", "group version", gvk.GroupVersion().String(), "error message", err)
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Kind == gvk.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceScoped returns true if cert-manager-controller is restricted to the
+// namespaces listed in the CR
+func namespaceScoped(instance *operatorv1.CertManagerConfig) bool {
+	return len(instance.Spec.WatchNamespaces) > 0
+}
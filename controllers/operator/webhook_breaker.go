@@ -0,0 +1,166 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	admRegv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+const (
+	circuitActionIgnore   = "Ignore"
+	circuitActionScopeOut = "ScopeOut"
+	// circuitScopeOutLabel is the label of the object selector matching no
+	// object while the circuit is open with ScopeOut
+	circuitScopeOutLabel = "operator.ibm.com/webhook-circuit-open"
+
+	defaultOutageDuration = 5 * time.Minute
+)
+
+// webhookCircuit tracks the outage of cert-manager-webhook between reconciles
+type webhookCircuit struct {
+	// outageSince is when cert-manager-webhook was first seen without ready
+	// endpoints, zero while it has some
+	outageSince time.Time
+	// recheck is how long until the circuit must be evaluated again, zero
+	// when no reconcile is needed
+	recheck time.Duration
+}
+
+func circuitBreaker(instance *operatorv1.CertManagerConfig) *operatorv1.WebhookCircuitBreakerSpec {
+	admission := instance.Spec.CertManagerWebhook.Admission
+	if admission == nil || admission.CircuitBreaker == nil || !admission.CircuitBreaker.Enabled {
+		return nil
+	}
+	return admission.CircuitBreaker
+}
+
+// webhookCircuitBreaker returns the action relaxing the webhooks while the
+// circuit is open, or an empty string while it is closed. Each transition is
+// recorded in the WebhookCircuitOpen condition and an event
+func (r *CertManagerReconciler) webhookCircuitBreaker(instance *operatorv1.CertManagerConfig) (string, error) {
+	r.circuit.recheck = 0
+	breaker := circuitBreaker(instance)
+	if breaker == nil {
+		r.circuit.outageSince = time.Time{}
+		r.removeCondition(instance, operatorv1.ConditionWebhookCircuitOpen)
+		return "", nil
+	}
+
+	ready, err := r.webhookEndpointsReady()
+	if err != nil {
+		return "", err
+	}
+	wasOpen := meta.IsStatusConditionTrue(instance.Status.Conditions, operatorv1.ConditionWebhookCircuitOpen)
+	if ready {
+		r.circuit.outageSince = time.Time{}
+		if wasOpen {
+			r.updateEvent(instance, "cert-manager-webhook has ready endpoints again, restored the admission settings of the webhooks", corev1.EventTypeNormal, "WebhookCircuitClosed")
+		}
+		r.setCondition(instance, metav1.Condition{
+			Type:    operatorv1.ConditionWebhookCircuitOpen,
+			Status:  metav1.ConditionFalse,
+			Reason:  "WebhookReady",
+			Message: "cert-manager-webhook has ready endpoints",
+		})
+		return "", nil
+	}
+
+	if r.circuit.outageSince.IsZero() {
+		r.circuit.outageSince = time.Now()
+	}
+	action := breaker.Action
+	if action == "" {
+		action = circuitActionIgnore
+	}
+	outage := durationOrDefault(breaker.OutageDuration, defaultOutageDuration)
+	if remaining := outage - time.Since(r.circuit.outageSince); !wasOpen && remaining > 0 {
+		// keep the webhooks until the outage lasts long enough
+		r.circuit.recheck = remaining
+		return "", nil
+	}
+	// poll for the recovery of cert-manager-webhook, in addition to the
+	// events of its deployment
+	r.circuit.recheck = time.Minute
+	if !wasOpen {
+		r.updateEvent(instance, fmt.Sprintf("cert-manager-webhook has had no ready endpoints for %s, relaxed the webhooks with %s", outage, action), corev1.EventTypeWarning, "WebhookCircuitOpened")
+	}
+	r.setCondition(instance, metav1.Condition{
+		Type:    operatorv1.ConditionWebhookCircuitOpen,
+		Status:  metav1.ConditionTrue,
+		Reason:  "WebhookUnavailable",
+		Message: fmt.Sprintf("cert-manager-webhook has no ready endpoints, the webhooks are relaxed with %s", action),
+	})
+	return action, nil
+}
+
+// webhookEndpointsReady returns true if the service of cert-manager-webhook
+// has at least one ready endpoint
+func (r *CertManagerReconciler) webhookEndpointsReady() (bool, error) {
+	endpoints := &corev1.Endpoints{}
+	// read from API server directly, endpoints are not cached
+	err := r.Reader.Get(context.Background(), types.NamespacedName{Name: res.CertManagerWebhookName, Namespace: r.NS}, endpoints)
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// relaxWebhook returns the failure policy, namespace selector and object
+// selector of a webhook relaxed with the action of the open circuit
+func relaxWebhook(action string, policy *admRegv1.FailurePolicyType, namespaceSelector, objectSelector *metav1.LabelSelector) (*admRegv1.FailurePolicyType, *metav1.LabelSelector, *metav1.LabelSelector) {
+	switch action {
+	case circuitActionIgnore:
+		ignore := admRegv1.Ignore
+		return &ignore, namespaceSelector, objectSelector
+	case circuitActionScopeOut:
+		// the namespace selector is not applied to cluster-scoped objects such
+		// as ClusterIssuers, while no object has a label which both exists and
+		// does not exist. Every namespace has the kubernetes.io/metadata.name
+		// label
+		return policy, &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: corev1.LabelMetadataName, Operator: metav1.LabelSelectorOpDoesNotExist},
+			},
+		}, &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: circuitScopeOutLabel, Operator: metav1.LabelSelectorOpExists},
+				{Key: circuitScopeOutLabel, Operator: metav1.LabelSelectorOpDoesNotExist},
+			},
+		}
+	}
+	return policy, namespaceSelector, objectSelector
+}
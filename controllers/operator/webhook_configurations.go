@@ -0,0 +1,196 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"bytes"
+
+	admRegv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+// webhookEntry points to the name and the fields of a mutating or validating
+// webhook which are reconciled by the operator. Other fields, e.g. the ones
+// defaulted by the API server, are kept
+type webhookEntry struct {
+	name                    *string
+	clientConfig            *admRegv1.WebhookClientConfig
+	rules                   *[]admRegv1.RuleWithOperations
+	failurePolicy           **admRegv1.FailurePolicyType
+	sideEffects             **admRegv1.SideEffectClass
+	admissionReviewVersions *[]string
+	timeoutSeconds          **int32
+	namespaceSelector       **metav1.LabelSelector
+	objectSelector          **metav1.LabelSelector
+}
+
+func mutatingEntry(w *admRegv1.MutatingWebhook) webhookEntry {
+	return webhookEntry{&w.Name, &w.ClientConfig, &w.Rules, &w.FailurePolicy, &w.SideEffects, &w.AdmissionReviewVersions, &w.TimeoutSeconds, &w.NamespaceSelector, &w.ObjectSelector}
+}
+
+func validatingEntry(w *admRegv1.ValidatingWebhook) webhookEntry {
+	return webhookEntry{&w.Name, &w.ClientConfig, &w.Rules, &w.FailurePolicy, &w.SideEffects, &w.AdmissionReviewVersions, &w.TimeoutSeconds, &w.NamespaceSelector, &w.ObjectSelector}
+}
+
+// syncWebhookEntry sets the reconciled fields of live to the ones of desired,
+// and returns the names of the fields which changed. The CA bundle of live is
+// kept when keepCABundle is true, i.e. when it is injected by
+// cert-manager-cainjector
+func syncWebhookEntry(live, desired webhookEntry, keepCABundle bool) []string {
+	var changed []string
+
+	clientConfig := *desired.clientConfig.DeepCopy()
+	if clientConfig.Service != nil && clientConfig.Service.Port == nil {
+		// defaulted by the API server
+		port := int32(443)
+		clientConfig.Service.Port = &port
+	}
+	if keepCABundle {
+		clientConfig.CABundle = live.clientConfig.CABundle
+	}
+	if !bytes.Equal(clientConfig.CABundle, live.clientConfig.CABundle) {
+		changed = append(changed, "caBundle")
+	}
+	liveClientConfig := live.clientConfig.DeepCopy()
+	liveClientConfig.CABundle = clientConfig.CABundle
+	if !equality.Semantic.DeepEqual(liveClientConfig, &clientConfig) {
+		changed = append(changed, "clientConfig")
+	}
+	*live.clientConfig = clientConfig
+
+	rules := make([]admRegv1.RuleWithOperations, len(*desired.rules))
+	for i := range *desired.rules {
+		rules[i] = *(*desired.rules)[i].DeepCopy()
+		if rules[i].Scope == nil {
+			// defaulted by the API server
+			scope := admRegv1.AllScopes
+			rules[i].Scope = &scope
+		}
+	}
+	if !equality.Semantic.DeepEqual(*live.rules, rules) {
+		changed = append(changed, "rules")
+	}
+	*live.rules = rules
+
+	if !equality.Semantic.DeepEqual(*live.failurePolicy, *desired.failurePolicy) {
+		changed = append(changed, "failurePolicy")
+	}
+	*live.failurePolicy = *desired.failurePolicy
+	if !equality.Semantic.DeepEqual(*live.sideEffects, *desired.sideEffects) {
+		changed = append(changed, "sideEffects")
+	}
+	*live.sideEffects = *desired.sideEffects
+	if !equality.Semantic.DeepEqual(*live.admissionReviewVersions, *desired.admissionReviewVersions) {
+		changed = append(changed, "admissionReviewVersions")
+	}
+	*live.admissionReviewVersions = *desired.admissionReviewVersions
+	if !equality.Semantic.DeepEqual(*live.timeoutSeconds, *desired.timeoutSeconds) {
+		changed = append(changed, "timeoutSeconds")
+	}
+	*live.timeoutSeconds = *desired.timeoutSeconds
+	if !equality.Semantic.DeepEqual(*live.namespaceSelector, *desired.namespaceSelector) {
+		changed = append(changed, "namespaceSelector")
+	}
+	*live.namespaceSelector = *desired.namespaceSelector
+	if !equality.Semantic.DeepEqual(*live.objectSelector, *desired.objectSelector) {
+		changed = append(changed, "objectSelector")
+	}
+	*live.objectSelector = *desired.objectSelector
+	return changed
+}
+
+// ownedWebhook returns true if the webhook calls cert-manager-webhook, i.e. it
+// is an entry of the operator rather than one added by other tooling
+func ownedWebhook(clientConfig admRegv1.WebhookClientConfig, ns string) bool {
+	return clientConfig.Service != nil && clientConfig.Service.Name == res.CertManagerWebhookName && clientConfig.Service.Namespace == ns
+}
+
+// webhookDiff describes the corrections made to the list of webhooks of a
+// webhook configuration
+type webhookDiff []string
+
+func (d *webhookDiff) add(format string, name string) {
+	*d = append(*d, format+" "+name)
+}
+
+func (d *webhookDiff) changed(name string, fields []string) {
+	for _, f := range fields {
+		*d = append(*d, "corrected "+f+" of "+name)
+	}
+}
+
+// reorderedDiff records a reordering when the names are not in the same
+// order, and nothing else changed
+func (d *webhookDiff) reordered(live, result []string) {
+	if len(*d) == 0 && !equality.Semantic.DeepEqual(live, result) {
+		*d = append(*d, "reordered webhooks")
+	}
+}
+
+// reconcileWebhooks returns the live webhooks of a mutating or validating
+// webhook configuration reconciled by name with the desired ones, in the
+// desired order. Entries added by other tooling are kept after them, stray
+// entries calling cert-manager-webhook are removed. entry is mutatingEntry or
+// validatingEntry
+func reconcileWebhooks[W any, P interface {
+	*W
+	DeepCopy() *W
+}](live, desired []W, entry func(*W) webhookEntry, ns string, keepCABundle bool) ([]W, webhookDiff) {
+	var diff webhookDiff
+	var result []W
+	var liveNames, resultNames []string
+	desiredNames := make(map[string]bool, len(desired))
+	for i := range desired {
+		desiredEntry := entry(&desired[i])
+		desiredNames[*desiredEntry.name] = true
+		found := false
+		for j := range live {
+			if *entry(&live[j]).name != *desiredEntry.name {
+				continue
+			}
+			w := *P(&live[j]).DeepCopy()
+			diff.changed(*desiredEntry.name, syncWebhookEntry(entry(&w), desiredEntry, keepCABundle))
+			result = append(result, w)
+			found = true
+			break
+		}
+		if !found {
+			diff.add("added", *desiredEntry.name)
+			result = append(result, *P(&desired[i]).DeepCopy())
+		}
+	}
+	for i := range live {
+		liveEntry := entry(&live[i])
+		liveNames = append(liveNames, *liveEntry.name)
+		if desiredNames[*liveEntry.name] {
+			continue
+		}
+		if ownedWebhook(*liveEntry.clientConfig, ns) {
+			diff.add("removed stray", *liveEntry.name)
+			continue
+		}
+		result = append(result, live[i])
+	}
+	for i := range result {
+		resultNames = append(resultNames, *entry(&result[i]).name)
+	}
+	diff.reordered(liveNames, resultNames)
+	return result, diff
+}
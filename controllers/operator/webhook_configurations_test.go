@@ -0,0 +1,175 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"reflect"
+	"testing"
+
+	admRegv1 "k8s.io/api/admissionregistration/v1"
+
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+const testWebhookNamespace = "ibm-cert-manager"
+
+// testWebhook returns a webhook calling cert-manager-webhook, or another
+// service when service is not empty, as read back from the API server
+func testWebhook(name, service, caBundle string) admRegv1.ValidatingWebhook {
+	if service == "" {
+		service = res.CertManagerWebhookName
+	}
+	port := int32(443)
+	scope := admRegv1.AllScopes
+	failurePolicy := admRegv1.Fail
+	sideEffects := admRegv1.SideEffectClassNone
+	return admRegv1.ValidatingWebhook{
+		Name: name,
+		ClientConfig: admRegv1.WebhookClientConfig{
+			Service:  &admRegv1.ServiceReference{Name: service, Namespace: testWebhookNamespace, Port: &port},
+			CABundle: []byte(caBundle),
+		},
+		Rules: []admRegv1.RuleWithOperations{{
+			Operations: []admRegv1.OperationType{admRegv1.Create, admRegv1.Update},
+			Rule:       admRegv1.Rule{APIGroups: []string{"cert-manager.io"}, APIVersions: []string{"v1"}, Resources: []string{"*/*"}, Scope: &scope},
+		}},
+		FailurePolicy:           &failurePolicy,
+		SideEffects:             &sideEffects,
+		AdmissionReviewVersions: []string{"v1"},
+	}
+}
+
+func webhookNames(webhooks []admRegv1.ValidatingWebhook) []string {
+	var names []string
+	for _, w := range webhooks {
+		names = append(names, w.Name)
+	}
+	return names
+}
+
+func TestReconcileWebhooks(t *testing.T) {
+	ignore := admRegv1.Ignore
+
+	tests := []struct {
+		name         string
+		live         []admRegv1.ValidatingWebhook
+		desired      []admRegv1.ValidatingWebhook
+		keepCABundle bool
+		wantNames    []string
+		wantDiff     webhookDiff
+		wantCABundle map[string]string
+	}{
+		{
+			name:         "in sync",
+			live:         []admRegv1.ValidatingWebhook{testWebhook("a", "", "ca"), testWebhook("b", "", "ca")},
+			desired:      []admRegv1.ValidatingWebhook{testWebhook("a", "", ""), testWebhook("b", "", "")},
+			keepCABundle: true,
+			wantNames:    []string{"a", "b"},
+			wantCABundle: map[string]string{"a": "ca", "b": "ca"},
+		},
+		{
+			name:         "reordered",
+			live:         []admRegv1.ValidatingWebhook{testWebhook("b", "", "ca"), testWebhook("a", "", "ca")},
+			desired:      []admRegv1.ValidatingWebhook{testWebhook("a", "", ""), testWebhook("b", "", "")},
+			keepCABundle: true,
+			wantNames:    []string{"a", "b"},
+			wantDiff:     webhookDiff{"reordered webhooks"},
+			wantCABundle: map[string]string{"a": "ca", "b": "ca"},
+		},
+		{
+			name:         "missing entry added",
+			live:         []admRegv1.ValidatingWebhook{testWebhook("b", "", "ca")},
+			desired:      []admRegv1.ValidatingWebhook{testWebhook("a", "", ""), testWebhook("b", "", "")},
+			keepCABundle: true,
+			wantNames:    []string{"a", "b"},
+			wantDiff:     webhookDiff{"added a"},
+		},
+		{
+			name:         "stray entry removed, other tooling kept",
+			live:         []admRegv1.ValidatingWebhook{testWebhook("other", "other-webhook", ""), testWebhook("a", "", "ca"), testWebhook("stray", "", "ca")},
+			desired:      []admRegv1.ValidatingWebhook{testWebhook("a", "", "")},
+			keepCABundle: true,
+			wantNames:    []string{"a", "other"},
+			wantDiff:     webhookDiff{"removed stray stray"},
+		},
+		{
+			name: "drifted field corrected",
+			live: func() []admRegv1.ValidatingWebhook {
+				w := testWebhook("a", "", "ca")
+				w.FailurePolicy = &ignore
+				return []admRegv1.ValidatingWebhook{w}
+			}(),
+			desired:      []admRegv1.ValidatingWebhook{testWebhook("a", "", "")},
+			keepCABundle: true,
+			wantNames:    []string{"a"},
+			wantDiff:     webhookDiff{"corrected failurePolicy of a"},
+			wantCABundle: map[string]string{"a": "ca"},
+		},
+		{
+			name:         "CA bundle set by the operator",
+			live:         []admRegv1.ValidatingWebhook{testWebhook("a", "", "old")},
+			desired:      []admRegv1.ValidatingWebhook{testWebhook("a", "", "new")},
+			keepCABundle: false,
+			wantNames:    []string{"a"},
+			wantDiff:     webhookDiff{"corrected caBundle of a"},
+			wantCABundle: map[string]string{"a": "new"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, diff := reconcileWebhooks(tt.live, tt.desired, validatingEntry, testWebhookNamespace, tt.keepCABundle)
+			if names := webhookNames(result); !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("webhooks = %v, want %v", names, tt.wantNames)
+			}
+			if !reflect.DeepEqual(diff, tt.wantDiff) {
+				t.Errorf("diff = %v, want %v", diff, tt.wantDiff)
+			}
+			for _, w := range result {
+				if want, ok := tt.wantCABundle[w.Name]; ok && string(w.ClientConfig.CABundle) != want {
+					t.Errorf("caBundle of %s = %q, want %q", w.Name, w.ClientConfig.CABundle, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileMutatingWebhooks(t *testing.T) {
+	validating := testWebhook("a", "", "ca")
+	live := []admRegv1.MutatingWebhook{{
+		Name:                    validating.Name,
+		ClientConfig:            validating.ClientConfig,
+		Rules:                   validating.Rules,
+		FailurePolicy:           validating.FailurePolicy,
+		SideEffects:             validating.SideEffects,
+		AdmissionReviewVersions: validating.AdmissionReviewVersions,
+	}}
+	desired := []admRegv1.MutatingWebhook{*live[0].DeepCopy()}
+	desired[0].ClientConfig.CABundle = nil
+	desired[0].AdmissionReviewVersions = []string{"v1", "v1beta1"}
+
+	result, diff := reconcileWebhooks(live, desired, mutatingEntry, testWebhookNamespace, true)
+	if want := (webhookDiff{"corrected admissionReviewVersions of a"}); !reflect.DeepEqual(diff, want) {
+		t.Errorf("diff = %v, want %v", diff, want)
+	}
+	if len(result) != 1 || string(result[0].ClientConfig.CABundle) != "ca" {
+		t.Errorf("webhooks = %v, want a with the live CA bundle", result)
+	}
+	if len(live[0].AdmissionReviewVersions) != 1 {
+		t.Errorf("live webhooks were modified")
+	}
+}
@@ -0,0 +1,134 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	certmanagerv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/cert-manager/v1"
+	cmmeta "github.com/ibm/ibm-cert-manager-operator/v4/apis/meta.cert-manager/v1"
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+)
+
+const (
+	// webhookSelfTestInterval is how often the webhooks are tested while
+	// they work
+	webhookSelfTestInterval = 5 * time.Minute
+
+	defaultSelfTestNamespace = "default"
+	selfTestName             = "ibm-cert-manager-operator-selftest"
+
+	// webhookDeniedMessage is part of the error returned by the API server
+	// when cert-manager-webhook rejects a request, other webhooks such as the
+	// CertificatePolicy webhook are named differently
+	webhookDeniedMessage = `admission webhook "webhook.cert-manager.io" denied the request`
+)
+
+// webhookSelfTest caches the result of the last admission self-test, shared
+// between reconciles
+type webhookSelfTest struct {
+	lastRun time.Time
+	passed  bool
+}
+
+func selfTestNamespace(instance *operatorv1.CertManagerConfig) string {
+	admission := instance.Spec.CertManagerWebhook.Admission
+	if admission == nil || admission.SelfTestNamespace == "" {
+		return defaultSelfTestNamespace
+	}
+	return admission.SelfTestNamespace
+}
+
+// selfTestCertificate returns a Certificate for the self-test. The invalid one
+// passes the schema of the CRD but not the validation of cert-manager-webhook,
+// as it has no subject or SAN
+func selfTestCertificate(ns string, valid bool) *certmanagerv1.Certificate {
+	cert := &certmanagerv1.Certificate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      selfTestName,
+			Namespace: ns,
+		},
+		Spec: certmanagerv1.CertificateSpec{
+			SecretName: selfTestName,
+			IssuerRef:  cmmeta.ObjectReference{Name: selfTestName, Kind: "Issuer"},
+		},
+	}
+	if valid {
+		cert.Spec.DNSNames = []string{selfTestName + ".example.com"}
+	}
+	return cert
+}
+
+// testWebhook creates an invalid and a valid Certificate with server-side
+// dry-run, and reports in the WebhookFunctional condition whether the
+// invalid one was rejected by cert-manager-webhook and the valid one
+// accepted. The test runs every webhookSelfTestInterval, and on every
+// reconcile while it fails
+func (r *CertManagerReconciler) testWebhook(instance *operatorv1.CertManagerConfig, circuitAction string) {
+	if circuitAction != "" {
+		r.selfTest = webhookSelfTest{}
+		r.setCondition(instance, metav1.Condition{
+			Type:    operatorv1.ConditionWebhookFunctional,
+			Status:  metav1.ConditionUnknown,
+			Reason:  "CircuitOpen",
+			Message: "The webhooks are relaxed by the circuit breaker, the self-test is suspended",
+		})
+		return
+	}
+	if r.selfTest.passed && time.Since(r.selfTest.lastRun) < webhookSelfTestInterval {
+		return
+	}
+
+	ns := selfTestNamespace(instance)
+	condition := metav1.Condition{
+		Type:    operatorv1.ConditionWebhookFunctional,
+		Status:  metav1.ConditionTrue,
+		Reason:  "SelfTestPassed",
+		Message: fmt.Sprintf("cert-manager-webhook rejected an invalid Certificate and accepted a valid one in namespace %s", ns),
+	}
+	err := r.Client.Create(context.TODO(), selfTestCertificate(ns, false), client.DryRunAll)
+	switch {
+	case err == nil:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidCertificateAccepted"
+		condition.Message = fmt.Sprintf("An invalid Certificate was accepted in namespace %s: cert-manager-webhook was not called, check that its failure policy is not Ignore and that its selectors match the namespace", ns)
+	case !strings.Contains(err.Error(), webhookDeniedMessage):
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "WebhookUnreachable"
+		condition.Message = fmt.Sprintf("Calling cert-manager-webhook failed, check its CA bundle and the network policies allowing port 10250: %v", err)
+	default:
+		err = r.Client.Create(context.TODO(), selfTestCertificate(ns, true), client.DryRunAll)
+		if err != nil && !apiErrors.IsAlreadyExists(err) {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = "ValidCertificateRejected"
+			condition.Message = fmt.Sprintf("A valid Certificate was rejected in namespace %s: %v", ns, err)
+		}
+	}
+
+	r.selfTest = webhookSelfTest{lastRun: time.Now(), passed: condition.Status == metav1.ConditionTrue}
+	if !r.selfTest.passed {
+		logd.Info("Webhook self-test failed", "reason", condition.Reason, "message", condition.Message)
+	}
+	r.setCondition(instance, condition)
+}
@@ -0,0 +1,391 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+const (
+	webhookTLSModeOperator = "Operator"
+
+	// caKeyKey is the key of the private key of the CA in the serving secret.
+	// ca.crt holds the CA bundle, whose first certificate is the current CA
+	caKeyKey = "ca.key"
+
+	defaultWebhookCADuration  = 5 * 365 * 24 * time.Hour
+	defaultWebhookTLSDuration = 365 * 24 * time.Hour
+	defaultWebhookRenewBefore = 30 * 24 * time.Hour
+)
+
+// webhookTLSEnabled returns true if the operator issues the serving
+// certificate of cert-manager-webhook
+func webhookTLSEnabled(instance *operatorv1.CertManagerConfig) bool {
+	return instance.Spec.WebhookTLS != nil && instance.Spec.WebhookTLS.Mode == webhookTLSModeOperator
+}
+
+func durationOrDefault(d *metav1.Duration, def time.Duration) time.Duration {
+	if d == nil || d.Duration <= 0 {
+		return def
+	}
+	return d.Duration
+}
+
+// webhookCertificates is the content of the serving secret of
+// cert-manager-webhook in Operator mode
+type webhookCertificates struct {
+	ca      *x509.Certificate
+	caKey   *ecdsa.PrivateKey
+	bundle  []*x509.Certificate
+	serving *x509.Certificate
+}
+
+// webhookTLS issues and rotates the CA and serving certificate of
+// cert-manager-webhook in Operator mode, and returns the CA bundle to inject.
+// In Dynamic mode it removes the secret issued by the operator, so that
+// cert-manager-webhook generates its own CA, and returns a nil bundle
+func (r *CertManagerReconciler) webhookTLS(instance *operatorv1.CertManagerConfig) ([]byte, error) {
+	secret := &corev1.Secret{}
+	// read from API server directly, secrets are not cached
+	err := r.Reader.Get(context.Background(), types.NamespacedName{Name: res.WebhookServingSecret, Namespace: r.NS}, secret)
+	if err != nil && !apiErrors.IsNotFound(err) {
+		return nil, err
+	}
+	exists := err == nil
+	managed := exists && secret.Labels[res.WebhookTLSManagedLabel] == "true"
+
+	if !webhookTLSEnabled(instance) {
+		if managed {
+			logd.Info("Removing webhook serving secret issued by the operator " + res.WebhookServingSecret)
+			if err := r.Client.Delete(context.Background(), secret); err != nil && !apiErrors.IsNotFound(err) {
+				return nil, err
+			}
+		}
+		r.updateWebhookTLSStatus(instance, nil)
+		return nil, nil
+	}
+
+	spec := instance.Spec.WebhookTLS
+	caDuration := durationOrDefault(spec.CADuration, defaultWebhookCADuration)
+	duration := durationOrDefault(spec.Duration, defaultWebhookTLSDuration)
+	renewBefore := durationOrDefault(spec.RenewBefore, defaultWebhookRenewBefore)
+	if renewBefore >= duration || renewBefore >= caDuration {
+		return nil, fmt.Errorf("webhookTLS.renewBefore %s must be shorter than the CA duration %s and the certificate duration %s", renewBefore, caDuration, duration)
+	}
+
+	certs := &webhookCertificates{}
+	if managed {
+		// an unreadable secret is reissued from scratch
+		if certs, err = parseWebhookCertificates(secret); err != nil {
+			logd.Error(err, "Reissuing invalid webhook serving secret "+res.WebhookServingSecret)
+			certs = &webhookCertificates{}
+		}
+	}
+
+	certs, rotated, err := renewWebhookCertificates(certs, time.Now(), caDuration, renewBefore, res.WebhookDNSNames)
+	if err != nil {
+		return nil, err
+	}
+
+	data := secret.Data
+	if rotated {
+		if data, err = issueWebhookCertificates(certs, duration, res.WebhookDNSNames); err != nil {
+			return nil, err
+		}
+	}
+
+	desired := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      res.WebhookServingSecret,
+			Namespace: r.NS,
+			Labels:    map[string]string{res.WebhookTLSManagedLabel: "true"},
+			Annotations: map[string]string{
+				// keeps the secret from being removed as a secret of an
+				// older release
+				"cert-manager.io/allow-direct-injection": "true",
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: data,
+	}
+	if err := controllerutil.SetControllerReference(instance, desired, r.Scheme); err != nil {
+		logd.Error(err, "Error setting controller reference on webhook serving secret")
+	}
+	switch {
+	case !exists:
+		logd.Info("Creating webhook serving secret " + res.WebhookServingSecret)
+		if err := r.Client.Create(context.Background(), desired); err != nil {
+			return nil, err
+		}
+	case !managed || secret.Type != corev1.SecretTypeTLS:
+		// the secret generated by cert-manager-webhook holds its CA, and the
+		// type of a secret is immutable
+		logd.Info("Replacing webhook serving secret " + res.WebhookServingSecret)
+		if err := r.Client.Delete(context.Background(), secret); err != nil && !apiErrors.IsNotFound(err) {
+			return nil, err
+		}
+		if err := r.Client.Create(context.Background(), desired); err != nil {
+			return nil, err
+		}
+	case rotated:
+		logd.Info("Rotating webhook serving secret " + res.WebhookServingSecret)
+		secret.Labels = mergeLabels(secret.Labels, desired.Labels)
+		secret.Data = desired.Data
+		if err := r.Client.Update(context.Background(), secret); err != nil {
+			return nil, err
+		}
+	}
+	if rotated && exists {
+		r.updateEvent(instance, "Rotated the serving certificate of cert-manager-webhook", corev1.EventTypeNormal, "WebhookTLSRotated")
+	}
+
+	if certs, err = parseWebhookCertificates(&corev1.Secret{Data: data}); err != nil {
+		return nil, err
+	}
+	r.updateWebhookTLSStatus(instance, &operatorv1.WebhookTLSStatus{
+		CANotAfter: metav1.NewTime(certs.ca.NotAfter),
+		NotAfter:   metav1.NewTime(certs.serving.NotAfter),
+	})
+	return data[corev1.ServiceAccountRootCAKey], nil
+}
+
+func (r *CertManagerReconciler) updateWebhookTLSStatus(instance *operatorv1.CertManagerConfig, status *operatorv1.WebhookTLSStatus) {
+	if equality.Semantic.DeepEqual(instance.Status.WebhookTLS, status) {
+		return
+	}
+	instance.Status.WebhookTLS = status
+	if err := r.Client.Status().Update(context.TODO(), instance); err != nil {
+		logd.Error(err, "Error updating instance status")
+	}
+}
+
+// renewWebhookCertificates rotates the CA when it is due for renewal, and
+// returns true if the serving certificate has to be reissued
+func renewWebhookCertificates(certs *webhookCertificates, now time.Time, caDuration, renewBefore time.Duration, dnsNames []string) (*webhookCertificates, bool, error) {
+	rotated := false
+	if certs.ca == nil || now.Add(renewBefore).After(certs.ca.NotAfter) {
+		ca, caKey, err := newWebhookCA(caDuration, res.CertManagerWebhookName+"-ca")
+		if err != nil {
+			return nil, false, err
+		}
+		// the previous CA stays in the bundle until it expires, so that pods
+		// still serving the previous certificate are trusted
+		bundle := []*x509.Certificate{ca}
+		if certs.ca != nil && now.Before(certs.ca.NotAfter) {
+			bundle = append(bundle, certs.ca)
+		}
+		certs = &webhookCertificates{ca: ca, caKey: caKey, bundle: bundle}
+		rotated = true
+	}
+	if certs.serving == nil || now.Add(renewBefore).After(certs.serving.NotAfter) ||
+		certs.serving.CheckSignatureFrom(certs.ca) != nil || !equality.Semantic.DeepEqual(certs.serving.DNSNames, dnsNames) {
+		rotated = true
+	}
+	return certs, rotated, nil
+}
+
+// parseWebhookCertificates reads the CA, its key, the CA bundle and the
+// serving certificate from the serving secret
+func parseWebhookCertificates(secret *corev1.Secret) (*webhookCertificates, error) {
+	bundle, err := parseCertificates(secret.Data[corev1.ServiceAccountRootCAKey])
+	if err != nil {
+		return nil, err
+	}
+	serving, err := parseCertificates(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(secret.Data[caKeyKey])
+	if block == nil {
+		return nil, errors.New("no CA private key found")
+	}
+	caKey, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	ca := bundle[0]
+	if !now.Before(ca.NotAfter) {
+		return nil, errors.New("the CA has expired")
+	}
+	// drop the expired previous CAs from the bundle
+	valid := []*x509.Certificate{ca}
+	for _, c := range bundle[1:] {
+		if now.Before(c.NotAfter) {
+			valid = append(valid, c)
+		}
+	}
+	return &webhookCertificates{ca: ca, caKey: caKey, bundle: valid, serving: serving[0]}, nil
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	return certs, nil
+}
+
+// newWebhookCA issues a self-signed CA
+func newWebhookCA(duration time.Duration, commonName string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             now.Add(-5 * time.Minute),
+		NotAfter:              now.Add(duration),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ca, key, nil
+}
+
+// issueWebhookCertificates issues a serving certificate for the DNS names,
+// signed by the CA, and returns the data of the serving secret
+func issueWebhookCertificates(certs *webhookCertificates, duration time.Duration, dnsNames []string) (map[string][]byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	notAfter := now.Add(duration)
+	if notAfter.After(certs.ca.NotAfter) {
+		notAfter = certs.ca.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: dnsNames[len(dnsNames)-1]},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, certs.ca, key.Public(), certs.caKey)
+	if err != nil {
+		return nil, err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	caKeyDer, err := x509.MarshalECPrivateKey(certs.caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &bytes.Buffer{}
+	for _, c := range certs.bundle {
+		if err := pem.Encode(bundle, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}); err != nil {
+			return nil, err
+		}
+	}
+	return map[string][]byte{
+		corev1.TLSCertKey:              pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		corev1.TLSPrivateKeyKey:        pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+		corev1.ServiceAccountRootCAKey: bundle.Bytes(),
+		caKeyKey:                       pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: caKeyDer}),
+	}, nil
+}
+
+// injectConversionCABundle sets the CA bundle of the conversion webhooks of
+// the cert-manager CRDs served by cert-manager-webhook
+func (r *CertManagerReconciler) injectConversionCABundle(caBundle []byte) error {
+	crds, err := r.APIextclient.ApiextensionsV1().CustomResourceDefinitions().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for i := range crds.Items {
+		crd := &crds.Items[i]
+		if crd.Spec.Group != "cert-manager.io" && crd.Spec.Group != "acme.cert-manager.io" {
+			continue
+		}
+		conversion := crd.Spec.Conversion
+		if conversion == nil || conversion.Strategy != apiextensionsv1.WebhookConverter || conversion.Webhook == nil ||
+			conversion.Webhook.ClientConfig == nil || conversion.Webhook.ClientConfig.Service == nil ||
+			conversion.Webhook.ClientConfig.Service.Name != res.CertManagerWebhookName {
+			continue
+		}
+		if bytes.Equal(conversion.Webhook.ClientConfig.CABundle, caBundle) {
+			continue
+		}
+		logd.Info("Injecting CA bundle into CRD " + crd.Name)
+		conversion.Webhook.ClientConfig.CABundle = caBundle
+		if _, err := r.APIextclient.ApiextensionsV1().CustomResourceDefinitions().Update(context.TODO(), crd, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
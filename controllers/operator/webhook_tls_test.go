@@ -0,0 +1,341 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package operator
+
+import (
+	"context"
+	"crypto/x509"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1 "github.com/ibm/ibm-cert-manager-operator/v4/apis/operator/v1"
+	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
+)
+
+var testDNSNames = []string{"cert-manager-webhook", "cert-manager-webhook.ibm-cert-manager.svc"}
+
+// testWebhookCA returns a CA valid for the given duration from now, a
+// negative duration returns an expired CA
+func testWebhookCA(t *testing.T, duration time.Duration) *webhookCertificates {
+	t.Helper()
+	ca, caKey, err := newWebhookCA(duration, "test-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &webhookCertificates{ca: ca, caKey: caKey, bundle: []*x509.Certificate{ca}}
+}
+
+// testWebhookSecret issues a serving certificate for the DNS names signed by
+// the CA, and returns the serving secret
+func testWebhookSecret(t *testing.T, certs *webhookCertificates, duration time.Duration, dnsNames []string) *corev1.Secret {
+	t.Helper()
+	data, err := issueWebhookCertificates(certs, duration, dnsNames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &corev1.Secret{Data: data}
+}
+
+func TestParseWebhookCertificates(t *testing.T) {
+	current := testWebhookCA(t, 24*time.Hour)
+	previous := testWebhookCA(t, time.Hour)
+	expired := testWebhookCA(t, -time.Minute)
+
+	tests := []struct {
+		name       string
+		secret     func() *corev1.Secret
+		wantErr    bool
+		wantBundle []*x509.Certificate
+	}{
+		{
+			name: "single CA",
+			secret: func() *corev1.Secret {
+				return testWebhookSecret(t, current, time.Hour, testDNSNames)
+			},
+			wantBundle: []*x509.Certificate{current.ca},
+		},
+		{
+			name: "previous CA kept until it expires",
+			secret: func() *corev1.Secret {
+				certs := *current
+				certs.bundle = []*x509.Certificate{current.ca, previous.ca}
+				return testWebhookSecret(t, &certs, time.Hour, testDNSNames)
+			},
+			wantBundle: []*x509.Certificate{current.ca, previous.ca},
+		},
+		{
+			name: "expired previous CA dropped",
+			secret: func() *corev1.Secret {
+				certs := *current
+				certs.bundle = []*x509.Certificate{current.ca, expired.ca, previous.ca}
+				return testWebhookSecret(t, &certs, time.Hour, testDNSNames)
+			},
+			wantBundle: []*x509.Certificate{current.ca, previous.ca},
+		},
+		{
+			name: "expired CA",
+			secret: func() *corev1.Secret {
+				return testWebhookSecret(t, expired, time.Hour, testDNSNames)
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing CA key",
+			secret: func() *corev1.Secret {
+				secret := testWebhookSecret(t, current, time.Hour, testDNSNames)
+				delete(secret.Data, caKeyKey)
+				return secret
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing serving certificate",
+			secret: func() *corev1.Secret {
+				secret := testWebhookSecret(t, current, time.Hour, testDNSNames)
+				delete(secret.Data, corev1.TLSCertKey)
+				return secret
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs, err := parseWebhookCertificates(tt.secret())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error: %t", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !certs.ca.Equal(current.ca) || !certs.caKey.Equal(current.caKey) {
+				t.Errorf("CA is not the current CA")
+			}
+			if len(certs.bundle) != len(tt.wantBundle) {
+				t.Fatalf("bundle has %d certificates, want %d", len(certs.bundle), len(tt.wantBundle))
+			}
+			for i := range certs.bundle {
+				if !certs.bundle[i].Equal(tt.wantBundle[i]) {
+					t.Errorf("bundle[%d] = %s, want %s", i, certs.bundle[i].NotAfter, tt.wantBundle[i].NotAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestIssueWebhookCertificates(t *testing.T) {
+	tests := []struct {
+		name         string
+		caDuration   time.Duration
+		duration     time.Duration
+		wantNotAfter func(ca *webhookCertificates) time.Time
+	}{
+		{
+			name:       "duration within the CA",
+			caDuration: 48 * time.Hour,
+			duration:   24 * time.Hour,
+		},
+		{
+			name:       "duration capped at the CA",
+			caDuration: time.Hour,
+			duration:   24 * time.Hour,
+			wantNotAfter: func(ca *webhookCertificates) time.Time {
+				return ca.ca.NotAfter
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ca := testWebhookCA(t, tt.caDuration)
+			before := time.Now()
+			certs, err := parseWebhookCertificates(testWebhookSecret(t, ca, tt.duration, testDNSNames))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := certs.serving.CheckSignatureFrom(ca.ca); err != nil {
+				t.Errorf("serving certificate is not signed by the CA: %v", err)
+			}
+			if !reflect.DeepEqual(certs.serving.DNSNames, testDNSNames) {
+				t.Errorf("DNS names = %v, want %v", certs.serving.DNSNames, testDNSNames)
+			}
+			if tt.wantNotAfter != nil {
+				if want := tt.wantNotAfter(ca); !certs.serving.NotAfter.Equal(want) {
+					t.Errorf("NotAfter = %s, want %s", certs.serving.NotAfter, want)
+				}
+			} else if certs.serving.NotAfter.Before(before.Add(tt.duration).Truncate(time.Second)) {
+				t.Errorf("NotAfter = %s, want %s from now", certs.serving.NotAfter, tt.duration)
+			}
+		})
+	}
+}
+
+func TestRenewWebhookCertificates(t *testing.T) {
+	renewBefore := 2 * time.Hour
+	current := testWebhookCA(t, 24*time.Hour)
+	dueCA := testWebhookCA(t, time.Hour)
+	other := testWebhookCA(t, 24*time.Hour)
+
+	// parsed returns the content of a serving secret issued by the signer
+	// with the CA of certs
+	parsed := func(certs, signer *webhookCertificates, duration time.Duration, dnsNames []string) *webhookCertificates {
+		secret := testWebhookSecret(t, signer, duration, dnsNames)
+		serving, err := parseCertificates(secret.Data[corev1.TLSCertKey])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &webhookCertificates{ca: certs.ca, caKey: certs.caKey, bundle: certs.bundle, serving: serving[0]}
+	}
+
+	tests := []struct {
+		name           string
+		certs          *webhookCertificates
+		wantReissue    bool
+		wantCARotation bool
+	}{
+		{
+			name:           "new secret",
+			certs:          &webhookCertificates{},
+			wantReissue:    true,
+			wantCARotation: true,
+		},
+		{
+			name:  "up to date",
+			certs: parsed(current, current, 12*time.Hour, testDNSNames),
+		},
+		{
+			name:        "serving certificate due for renewal",
+			certs:       parsed(current, current, time.Hour, testDNSNames),
+			wantReissue: true,
+		},
+		{
+			name:        "DNS names changed",
+			certs:       parsed(current, current, 12*time.Hour, testDNSNames[:1]),
+			wantReissue: true,
+		},
+		{
+			name:        "signed by another CA",
+			certs:       parsed(current, other, 12*time.Hour, testDNSNames),
+			wantReissue: true,
+		},
+		{
+			name:           "CA due for renewal",
+			certs:          parsed(dueCA, dueCA, time.Hour, testDNSNames),
+			wantReissue:    true,
+			wantCARotation: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs, reissue, err := renewWebhookCertificates(tt.certs, time.Now(), 48*time.Hour, renewBefore, testDNSNames)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reissue != tt.wantReissue {
+				t.Errorf("reissue = %t, want %t", reissue, tt.wantReissue)
+			}
+			if rotated := certs.ca != tt.certs.ca; rotated != tt.wantCARotation {
+				t.Fatalf("CA rotated = %t, want %t", rotated, tt.wantCARotation)
+			}
+			if !tt.wantCARotation {
+				return
+			}
+			// the previous CA stays in ca.crt next to the new CA
+			want := []*x509.Certificate{certs.ca}
+			if tt.certs.ca != nil {
+				want = append(want, tt.certs.ca)
+			}
+			issued, err := parseWebhookCertificates(testWebhookSecret(t, certs, 24*time.Hour, testDNSNames))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(issued.bundle) != len(want) {
+				t.Fatalf("ca.crt has %d certificates, want %d", len(issued.bundle), len(want))
+			}
+			for i := range want {
+				if !issued.bundle[i].Equal(want[i]) {
+					t.Errorf("ca.crt[%d] is not the expected CA", i)
+				}
+			}
+			if err := issued.serving.CheckSignatureFrom(certs.ca); err != nil {
+				t.Errorf("serving certificate is not signed by the new CA: %v", err)
+			}
+		})
+	}
+}
+
+func TestWebhookTLSDynamicMode(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := operatorv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	servingSecret := func(labels map[string]string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: res.WebhookServingSecret, Namespace: testWebhookNamespace, Labels: labels},
+			Type:       corev1.SecretTypeTLS,
+		}
+	}
+
+	tests := []struct {
+		name        string
+		secret      *corev1.Secret
+		wantDeleted bool
+	}{
+		{
+			name:        "secret issued by the operator removed",
+			secret:      servingSecret(map[string]string{res.WebhookTLSManagedLabel: "true"}),
+			wantDeleted: true,
+		},
+		{
+			name:   "secret generated by cert-manager-webhook kept",
+			secret: servingSecret(nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &operatorv1.CertManagerConfig{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance, tt.secret).WithStatusSubresource(instance).Build()
+			r := &CertManagerReconciler{Client: c, Reader: c, Scheme: scheme, NS: testWebhookNamespace}
+
+			caBundle, err := r.webhookTLS(instance)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if caBundle != nil {
+				t.Errorf("caBundle = %q, want none", caBundle)
+			}
+			err = c.Get(context.Background(), types.NamespacedName{Name: res.WebhookServingSecret, Namespace: testWebhookNamespace}, &corev1.Secret{})
+			if deleted := apiErrors.IsNotFound(err); deleted != tt.wantDeleted {
+				t.Errorf("secret deleted = %t, want %t (err %v)", deleted, tt.wantDeleted, err)
+			}
+		})
+	}
+}
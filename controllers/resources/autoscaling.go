@@ -0,0 +1,74 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package resources
+
+import (
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// DefaultMinReplicas is the default lower limit of replicas of an autoscaled operand
+var DefaultMinReplicas int32 = 1
+
+// DefaultMaxReplicas is the default upper limit of replicas of an autoscaled operand
+var DefaultMaxReplicas int32 = 3
+
+// DefaultTargetCPUUtilization is the default average CPU utilization percentage
+// an autoscaled operand is scaled on
+var DefaultTargetCPUUtilization int32 = 80
+
+// DefaultVPAUpdateMode is the default update mode of the VerticalPodAutoscalers
+const DefaultVPAUpdateMode = "Auto"
+
+// VPAGroupVersionKind is the GroupVersionKind of the VerticalPodAutoscaler
+// API, which is only present when the autoscaler is installed on the cluster
+var VPAGroupVersionKind = schema.GroupVersionKind{
+	Group:   "autoscaling.k8s.io",
+	Version: "v1",
+	Kind:    "VerticalPodAutoscaler",
+}
+
+// WebhookHPA is the HorizontalPodAutoscaler definition for cert-manager-webhook
+var WebhookHPA = &autoscalingv2.HorizontalPodAutoscaler{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:   CertManagerWebhookName,
+		Labels: WebhookLabelMap,
+	},
+	Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+		ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       CertManagerWebhookName,
+		},
+		MinReplicas: &DefaultMinReplicas,
+		MaxReplicas: DefaultMaxReplicas,
+		Metrics: []autoscalingv2.MetricSpec{
+			{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name: corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{
+						Type:               autoscalingv2.UtilizationMetricType,
+						AverageUtilization: &DefaultTargetCPUUtilization,
+					},
+				},
+			},
+		},
+	},
+}
@@ -16,51 +16,12 @@
 
 package resources
 
+import (
+	_ "embed"
+)
+
+// CertManagerConfigCR is the default CertManagerConfig created at startup
 // base on doc https://www.ibm.com/docs/en/cpfs?topic=services-configuring-foundational-by-using-custom-resource#cert_resources
-const CertManagerConfigCR = `
-apiVersion: operator.ibm.com/v1
-kind: CertManagerConfig
-metadata:
-  labels:
-    app.kubernetes.io/instance: ibm-cert-manager-operator
-    app.kubernetes.io/managed-by: ibm-cert-manager-operator
-    app.kubernetes.io/name: cert-manager
-  name: default
-spec:
-  certManagerCAInjector:
-    resources:
-      limits:
-        cpu: 100m
-        memory: 1000Mi
-      requests:
-        cpu: 30m
-        memory: 500Mi
-        ephemeral-storage: 256Mi
-  certManagerController:
-    resources:
-      limits:
-        cpu: 80m
-        memory: 1010Mi
-      requests:
-        cpu: 20m
-        memory: 230Mi
-        ephemeral-storage: 510Mi
-  certManagerWebhook:
-    resources:
-      limits:
-        cpu: 60m
-        memory: 100Mi
-      requests:
-        cpu: 30m
-        memory: 40Mi
-        ephemeral-storage: 256Mi
-  disableHostNetwork: true
-  enableCertRefresh: true
-  enableWebhook: true
-  imageRegistry: icr.io/cpopen/cpfs
-  license:
-    accept: false
-  version: 4.2.22
-status:
-  certManagerConfigStatus: ''
-`
+//
+//go:embed manifests/certmanagerconfig.yaml
+var CertManagerConfigCR string
@@ -39,7 +39,6 @@ var memory300 = resource.NewQuantity(300*1024*1024, resource.BinarySI) // 300Mi
 var memory500 = resource.NewQuantity(500*1024*1024, resource.BinarySI) // 500Mi
 
 var replicaCount int32 = 1
-var timeoutSecondsWebhook int32 = 10
 
 const certManagerComponentName = "cert-manager"
 
@@ -97,6 +96,41 @@ const CainjectorLabels = "app=ibm-cert-manager-cainjector"
 // SecretWatchLabel is a string of secrets that watched by cert manager operator labels
 const SecretWatchLabel string = "operator.ibm.com/watched-by-cert-manager"
 
+// SecretHashAnnotation records on a workload the hash of the data of the
+// watched secrets it references
+const SecretHashAnnotation = "operator.ibm.com/watched-secrets-hash"
+
+// SecretRolloutAnnotation is set on the pod template of a workload to roll it
+// out when a watched secret it references changes
+const SecretRolloutAnnotation = "operator.ibm.com/secrets-rolled-out-at"
+
+// SecretRolloutOptOutAnnotation disables the rollout of a workload when the
+// watched secrets it references change, when set to true
+const SecretRolloutOptOutAnnotation = "operator.ibm.com/disable-secret-rollout"
+
+// CertificateInventoryName is the name of the ConfigMap listing the
+// certificates of the cluster, in the deploy namespace
+const CertificateInventoryName = "cert-manager-certificate-inventory"
+
+// PolicyWebhookName is the name of the service, serving secret and
+// ValidatingWebhookConfiguration of the CertificatePolicy webhook served by
+// the operator
+const PolicyWebhookName = "ibm-cert-manager-operator-policy-webhook"
+
+// PolicyWebhookPath is the path the operator serves the CertificatePolicy
+// webhook on
+const PolicyWebhookPath = "/validate-certificate-policy"
+
+// PolicyWebhookPort is the port the operator serves its webhooks on
+const PolicyWebhookPort = 9443
+
+// OperatorPodLabels are the labels selecting the pods of the operator
+var OperatorPodLabels = map[string]string{"name": "ibm-cert-manager-operator"}
+
+// DefaultIssuerLabel marks the ClusterIssuers and the Certificate of the chain
+// of default issuers reconciled by the operator
+const DefaultIssuerLabel = "operator.ibm.com/default-issuer"
+
 // DefaultNamespace is the namespace the cert-manager services will be deployed in if the operator is deployed in all namespaces or locally
 const DefaultNamespace = "ibm-cert-manager"
 
@@ -207,11 +241,37 @@ var readinessExecActionWebhook = v1.ExecAction{
 // WebhookServingSecret is the name of tls secret used for serving the cert-manager-webhook
 const WebhookServingSecret = "cert-manager-webhook-ca"
 
+// WebhookTLSVolumeName is the name of the volume of the serving secret of
+// cert-manager-webhook, mounted when the operator issues the certificate
+const WebhookTLSVolumeName = "tls"
+
+// WebhookTLSMountPath is where the serving secret of cert-manager-webhook is
+// mounted when the operator issues the certificate
+const WebhookTLSMountPath = "/var/run/secrets/cert-manager-webhook"
+
+// WebhookTLSManagedLabel marks the serving secret of cert-manager-webhook
+// when it is issued by the operator
+const WebhookTLSManagedLabel = "operator.ibm.com/webhook-tls"
+
+// InjectCAFromSecretAnnotation makes cert-manager-cainjector inject the CA of
+// a secret into the annotated webhook configuration or CRD
+const InjectCAFromSecretAnnotation = "cert-manager.io/inject-ca-from-secret"
+
 // ResourceNS is the resource namespace arg for cert-manager-controller
 var ResourceNS = "--cluster-resource-namespace=" + DeployNamespace
 
 const leaderElectNS = "--leader-election-namespace=cert-manager"
 
+// LogLevelArg is the arg prefix used to set the log verbosity of an operand
+const LogLevelArg = "--v="
+
+// LogFormatArg is the arg prefix used to set the log format of an operand
+const LogFormatArg = "--logging-format="
+
+// NamespaceArg is the arg prefix restricting cert-manager-controller to a
+// single namespace
+const NamespaceArg = "--namespace="
+
 // AcmeSolverArg is the acme solver image to use for the cert-manager-controller
 var AcmeSolverArg = "--acme-http01-solver-image=" + acmesolverImage
 
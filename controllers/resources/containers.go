@@ -53,7 +53,9 @@ var containerSecurityWebhook = &corev1.SecurityContext{
 	},
 }
 
-var cpuMemory = corev1.ResourceRequirements{
+// DefaultResources are the resource requirements of an operand when neither a
+// profile nor explicit resources are set in the CR
+var DefaultResources = corev1.ResourceRequirements{
 	Limits: map[corev1.ResourceName]resource.Quantity{
 		corev1.ResourceCPU:    *cpu500,
 		corev1.ResourceMemory: *memory500},
@@ -63,6 +65,17 @@ var cpuMemory = corev1.ResourceRequirements{
 		corev1.ResourceEphemeralStorage: *memory256},
 }
 
+// WebhookDefaultArgs are the default arguments used for cert-manager-webhook
+var WebhookDefaultArgs = []string{"--v=2", "--secure-port=10250", "--dynamic-serving-ca-secret-namespace=" + DeployNamespace, "--dynamic-serving-ca-secret-name=" + WebhookServingSecret, "--dynamic-serving-dns-names=" + strings.Join(WebhookDNSNames, ",")}
+
+// WebhookOperatorTLSArgs are the arguments used for cert-manager-webhook when
+// its serving certificate is issued by the operator. The certificate files are
+// reloaded by cert-manager-webhook when the secret is rotated
+var WebhookOperatorTLSArgs = []string{"--v=2", "--secure-port=10250", "--tls-cert-file=" + WebhookTLSMountPath + "/tls.crt", "--tls-private-key-file=" + WebhookTLSMountPath + "/tls.key"}
+
+// WebhookDNSNames are the DNS names of the service of cert-manager-webhook
+var WebhookDNSNames = []string{CertManagerWebhookName, CertManagerWebhookName + "." + DeployNamespace, CertManagerWebhookName + "." + DeployNamespace + ".svc"}
+
 var controllerContainer = corev1.Container{
 	Name:            CertManagerControllerName,
 	Image:           controllerImage,
@@ -101,14 +114,14 @@ var controllerContainer = corev1.Container{
 		FailureThreshold:    failureThresholdReadiness,
 	},
 	SecurityContext: containerSecurityGeneral,
-	Resources:       cpuMemory,
+	Resources:       DefaultResources,
 }
 
 var webhookContainer = corev1.Container{
 	Name:            CertManagerWebhookName,
 	Image:           webhookImage,
 	ImagePullPolicy: pullPolicy,
-	Args:            []string{"--v=2", "--secure-port=10250", "--dynamic-serving-ca-secret-namespace=" + DeployNamespace, "--dynamic-serving-ca-secret-name=" + WebhookServingSecret, "--dynamic-serving-dns-names=" + strings.Join([]string{CertManagerWebhookName, CertManagerWebhookName + "." + DeployNamespace, CertManagerWebhookName + "." + DeployNamespace + ".svc"}, ",")},
+	Args:            WebhookDefaultArgs,
 	Env: []corev1.EnvVar{
 		{
 			Name: "POD_NAMESPACE",
@@ -145,7 +158,7 @@ var webhookContainer = corev1.Container{
 		FailureThreshold:    failureThresholdReadiness,
 	},
 	SecurityContext: containerSecurityWebhook,
-	Resources:       cpuMemory,
+	Resources:       DefaultResources,
 }
 
 var cainjectorContainer = corev1.Container{
@@ -181,5 +194,5 @@ var cainjectorContainer = corev1.Container{
 		FailureThreshold:    failureThresholdReadiness,
 	},
 	SecurityContext: containerSecurityGeneral,
-	Resources:       cpuMemory,
+	Resources:       DefaultResources,
 }
@@ -20,8 +20,37 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+var revisionHistoryLimit int32 = 10
+var rollingMaxUnavailable = intstr.FromString("25%")
+var rollingMaxSurge = intstr.FromString("25%")
+var hostNetworkMaxUnavailable = intstr.FromInt32(1)
+var hostNetworkMaxSurge = intstr.FromInt32(0)
+
+// DefaultDeploymentStrategy is the rollout strategy used for the operand
+// deployments
+var DefaultDeploymentStrategy = appsv1.DeploymentStrategy{
+	Type: appsv1.RollingUpdateDeploymentStrategyType,
+	RollingUpdate: &appsv1.RollingUpdateDeployment{
+		MaxUnavailable: &rollingMaxUnavailable,
+		MaxSurge:       &rollingMaxSurge,
+	},
+}
+
+// HostNetworkDeploymentStrategy is the rollout strategy used for operand
+// deployments running on the host network. A surge pod can not bind the host
+// port while the old pod is still running on the same node, so the old pod is
+// removed first
+var HostNetworkDeploymentStrategy = appsv1.DeploymentStrategy{
+	Type: appsv1.RollingUpdateDeploymentStrategyType,
+	RollingUpdate: &appsv1.RollingUpdateDeployment{
+		MaxUnavailable: &hostNetworkMaxUnavailable,
+		MaxSurge:       &hostNetworkMaxSurge,
+	},
+}
+
 // ControllerDeployment is the deployment template for deploying the cert-manager-controller
 var ControllerDeployment = &appsv1.Deployment{
 	ObjectMeta: metav1.ObjectMeta{
@@ -30,7 +59,9 @@ var ControllerDeployment = &appsv1.Deployment{
 		Labels: ControllerLabelMap,
 	},
 	Spec: appsv1.DeploymentSpec{
-		Replicas: &replicaCount,
+		Replicas:             &replicaCount,
+		RevisionHistoryLimit: &revisionHistoryLimit,
+		Strategy:             DefaultDeploymentStrategy,
 		Selector: &metav1.LabelSelector{
 			MatchLabels: OriginalControllerLabelMap,
 		},
@@ -52,7 +83,9 @@ var WebhookDeployment = &appsv1.Deployment{
 		Labels: WebhookLabelMap,
 	},
 	Spec: appsv1.DeploymentSpec{
-		Replicas: &replicaCount,
+		Replicas:             &replicaCount,
+		RevisionHistoryLimit: &revisionHistoryLimit,
+		Strategy:             DefaultDeploymentStrategy,
 		Selector: &metav1.LabelSelector{
 			MatchLabels: map[string]string{
 				"app": "ibm-cert-manager-webhook",
@@ -76,7 +109,9 @@ var CainjectorDeployment = &appsv1.Deployment{
 		Labels: CainjectorLabelMap,
 	},
 	Spec: appsv1.DeploymentSpec{
-		Replicas: &replicaCount,
+		Replicas:             &replicaCount,
+		RevisionHistoryLimit: &revisionHistoryLimit,
+		Strategy:             DefaultDeploymentStrategy,
 		Selector: &metav1.LabelSelector{
 			MatchLabels: OriginalCainjectorLabelMap,
 		},
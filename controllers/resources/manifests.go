@@ -0,0 +1,330 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package resources
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"text/template"
+
+	admRegv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// manifestFS holds the operand manifests. They are go templates rendered with
+// manifestValues. Refresh them from an upstream cert-manager release with
+// make update-operand-manifests
+//
+//go:embed manifests/rbac/*.yaml manifests/webhook.yaml
+var manifestFS embed.FS
+
+const (
+	rbacManifestDir         = "manifests/rbac"
+	aggregatedRbacManifest  = "aggregated.yaml"
+	webhookManifest         = "manifests/webhook.yaml"
+	rbacManifestsAPIVersion = "rbac.authorization.k8s.io/v1"
+)
+
+// manifestValues are the values the manifests are rendered with
+type manifestValues struct {
+	Namespace            string
+	WebhookName          string
+	WebhookApp           string
+	WebhookServingSecret string
+}
+
+// manifest is a single document of a manifest file
+type manifest struct {
+	file string
+	meta metav1.PartialObjectMetadata
+	data []byte
+}
+
+func (m manifest) String() string {
+	return fmt.Sprintf("%s %s in %s", m.meta.Kind, m.meta.Name, m.file)
+}
+
+// decode strictly decodes the manifest into obj, so that unknown or
+// misspelled fields are reported
+func (m manifest) decode(obj interface{}) error {
+	if err := yaml.UnmarshalStrict(m.data, obj); err != nil {
+		return fmt.Errorf("invalid %s: %v", m, err)
+	}
+	return nil
+}
+
+// LoadManifests renders the embedded operand manifests with the namespace of
+// the operator and loads them into the RBAC and webhook templates. It is
+// called once at startup: an error means that the manifests shipped with the
+// operator are invalid
+func LoadManifests() error {
+	values := manifestValues{
+		Namespace:            DeployNamespace,
+		WebhookName:          CertManagerWebhookName,
+		WebhookApp:           "ibm-cert-manager-webhook",
+		WebhookServingSecret: WebhookServingSecret,
+	}
+	if err := loadRbacManifests(values); err != nil {
+		return err
+	}
+	return loadWebhookManifests(values)
+}
+
+func loadRbacManifests(values manifestValues) error {
+	entries, err := fs.ReadDir(manifestFS, rbacManifestDir)
+	if err != nil {
+		return err
+	}
+
+	serviceAccounts := &corev1.ServiceAccountList{}
+	roles := &rbacv1.RoleList{}
+	roleBindings := &rbacv1.RoleBindingList{}
+	clusterRoles := &rbacv1.ClusterRoleList{}
+	aggregatedClusterRoles := &rbacv1.ClusterRoleList{}
+	clusterRoleBindings := &rbacv1.ClusterRoleBindingList{}
+	for _, entry := range entries {
+		manifests, err := renderManifests(path.Join(rbacManifestDir, entry.Name()), values)
+		if err != nil {
+			return err
+		}
+		for _, m := range manifests {
+			if m.meta.Kind != "ServiceAccount" && m.meta.APIVersion != rbacManifestsAPIVersion {
+				return fmt.Errorf("invalid %s: unexpected apiVersion %s", m, m.meta.APIVersion)
+			}
+			switch m.meta.Kind {
+			case "ServiceAccount":
+				obj := corev1.ServiceAccount{}
+				if err := m.decode(&obj); err != nil {
+					return err
+				}
+				serviceAccounts.Items = append(serviceAccounts.Items, obj)
+			case "Role":
+				obj := rbacv1.Role{}
+				if err := m.decode(&obj); err != nil {
+					return err
+				}
+				roles.Items = append(roles.Items, obj)
+			case "RoleBinding":
+				obj := rbacv1.RoleBinding{}
+				if err := m.decode(&obj); err != nil {
+					return err
+				}
+				roleBindings.Items = append(roleBindings.Items, obj)
+			case "ClusterRole":
+				obj := rbacv1.ClusterRole{}
+				if err := m.decode(&obj); err != nil {
+					return err
+				}
+				if entry.Name() == aggregatedRbacManifest {
+					aggregatedClusterRoles.Items = append(aggregatedClusterRoles.Items, obj)
+				} else {
+					clusterRoles.Items = append(clusterRoles.Items, obj)
+				}
+			case "ClusterRoleBinding":
+				obj := rbacv1.ClusterRoleBinding{}
+				if err := m.decode(&obj); err != nil {
+					return err
+				}
+				clusterRoleBindings.Items = append(clusterRoleBindings.Items, obj)
+			default:
+				return fmt.Errorf("invalid %s: unexpected kind", m)
+			}
+		}
+	}
+
+	for _, b := range roleBindings.Items {
+		if !hasRole(roles.Items, b.RoleRef) {
+			return fmt.Errorf("invalid RoleBinding %s: Role %s not found", b.Name, b.RoleRef.Name)
+		}
+	}
+	for _, b := range clusterRoleBindings.Items {
+		if findClusterRole(clusterRoles.Items, b.RoleRef.Name) == nil {
+			return fmt.Errorf("invalid ClusterRoleBinding %s: ClusterRole %s not found", b.Name, b.RoleRef.Name)
+		}
+	}
+
+	var namespaced, clusterScoped []*rbacv1.ClusterRole
+	for _, name := range controllerNamespacedClusterRoleNames {
+		r := findClusterRole(clusterRoles.Items, name)
+		if r == nil {
+			return fmt.Errorf("ClusterRole %s not found in the RBAC manifests", name)
+		}
+		namespaced = append(namespaced, r)
+	}
+	for _, name := range controllerClusterScopedClusterRoleNames {
+		r := findClusterRole(clusterRoles.Items, name)
+		if r == nil {
+			return fmt.Errorf("ClusterRole %s not found in the RBAC manifests", name)
+		}
+		clusterScoped = append(clusterScoped, r)
+	}
+	var controllerServiceAccount *corev1.ServiceAccount
+	for i := range serviceAccounts.Items {
+		if serviceAccounts.Items[i].Name == controllerServiceAccountName {
+			controllerServiceAccount = &serviceAccounts.Items[i]
+		}
+	}
+	if controllerServiceAccount == nil {
+		return fmt.Errorf("ServiceAccount %s not found in the RBAC manifests", controllerServiceAccountName)
+	}
+	var webhookRoleBinding *rbacv1.RoleBinding
+	for i := range roleBindings.Items {
+		if roleBindings.Items[i].Name == webhookRoleBindingName {
+			webhookRoleBinding = &roleBindings.Items[i]
+		}
+	}
+	if webhookRoleBinding == nil {
+		return fmt.Errorf("RoleBinding %s not found in the RBAC manifests", webhookRoleBindingName)
+	}
+
+	ServiceAccountsToCreate = serviceAccounts
+	RolesToCreate = roles
+	RoleBindingsToCreate = roleBindings
+	ClusterRolesToCreate = clusterRoles
+	AggregatedClusterRolesToCreate = aggregatedClusterRoles
+	ClusterRoleBindingsToCreate = clusterRoleBindings
+	ControllerNamespacedClusterRoles = namespaced
+	ControllerClusterScopedClusterRoles = clusterScoped
+	ControllerServiceAccount = controllerServiceAccount
+	WebhookRoleBinding = webhookRoleBinding
+	return nil
+}
+
+func loadWebhookManifests(values manifestValues) error {
+	manifests, err := renderManifests(webhookManifest, values)
+	if err != nil {
+		return err
+	}
+
+	var svc *corev1.Service
+	var mutating *admRegv1.MutatingWebhookConfiguration
+	var validating *admRegv1.ValidatingWebhookConfiguration
+	for _, m := range manifests {
+		switch m.meta.Kind {
+		case "Service":
+			svc = &corev1.Service{}
+			if err := m.decode(svc); err != nil {
+				return err
+			}
+		case "MutatingWebhookConfiguration":
+			mutating = &admRegv1.MutatingWebhookConfiguration{}
+			if err := m.decode(mutating); err != nil {
+				return err
+			}
+		case "ValidatingWebhookConfiguration":
+			validating = &admRegv1.ValidatingWebhookConfiguration{}
+			if err := m.decode(validating); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid %s: unexpected kind", m)
+		}
+	}
+	if svc == nil || mutating == nil || validating == nil {
+		return fmt.Errorf("%s must contain a Service, a MutatingWebhookConfiguration and a ValidatingWebhookConfiguration", webhookManifest)
+	}
+	if len(mutating.Webhooks) == 0 || len(validating.Webhooks) == 0 {
+		return fmt.Errorf("the webhook configurations in %s must contain at least one webhook", webhookManifest)
+	}
+
+	// the labels of the webhook configurations are the ones of
+	// cert-manager-webhook, which include the labels set in the CR
+	mutating.Labels = WebhookLabelMap
+	validating.Labels = WebhookLabelMap
+
+	WebhookSvc = svc
+	MutatingWebhook = mutating
+	ValidatingWebhook = validating
+	return nil
+}
+
+// renderManifests renders the manifest file with the given values and splits
+// it into its documents
+func renderManifests(file string, values manifestValues) ([]manifest, error) {
+	raw, err := manifestFS.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(file).Option("missingkey=error").Parse(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", file, err)
+	}
+	rendered := &bytes.Buffer{}
+	if err := tmpl.Execute(rendered, values); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s: %v", file, err)
+	}
+
+	var manifests []manifest
+	seen := make(map[string]bool)
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(rendered))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest %s: %v", file, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		m := manifest{file: file, data: doc}
+		if err := yaml.Unmarshal(doc, &m.meta); err != nil {
+			return nil, fmt.Errorf("invalid manifest %s: %v", file, err)
+		}
+		if m.meta.Kind == "" || m.meta.Name == "" {
+			return nil, fmt.Errorf("invalid manifest %s: every document needs a kind and a name", file)
+		}
+		if seen[m.meta.Kind+"/"+m.meta.Name] {
+			return nil, fmt.Errorf("invalid %s: duplicate object", m)
+		}
+		seen[m.meta.Kind+"/"+m.meta.Name] = true
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+func hasRole(roles []rbacv1.Role, ref rbacv1.RoleRef) bool {
+	if ref.Kind != "Role" {
+		return false
+	}
+	for _, r := range roles {
+		if r.Name == ref.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func findClusterRole(clusterRoles []rbacv1.ClusterRole, name string) *rbacv1.ClusterRole {
+	for i := range clusterRoles {
+		if clusterRoles[i].Name == name {
+			return &clusterRoles[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MetricsPortName is the name of the port of the metrics Services
+const MetricsPortName = "http-metrics"
+
+// MetricsComponentLabel is the label value distinguishing the metrics Services
+// from the other Services of an operand
+const MetricsComponentLabel = "metrics"
+
+// DefaultScrapeInterval is the default interval Prometheus scrapes the operand
+// metrics at
+const DefaultScrapeInterval = "60s"
+
+// ServiceMonitorGroupVersionKind is the GroupVersionKind of the ServiceMonitor
+// API, which is only present when the Prometheus operator is installed
+var ServiceMonitorGroupVersionKind = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "ServiceMonitor",
+}
+
+// ControllerMetricsSvc is the metrics service definition for cert-manager-controller
+var ControllerMetricsSvc = metricsService(CertManagerControllerName, "ibm-cert-manager-controller")
+
+// WebhookMetricsSvc is the metrics service definition for cert-manager-webhook
+var WebhookMetricsSvc = metricsService(CertManagerWebhookName, "ibm-cert-manager-webhook")
+
+// CainjectorMetricsSvc is the metrics service definition for cert-manager-cainjector
+var CainjectorMetricsSvc = metricsService(CertManagerCainjectorName, "ibm-cert-manager-cainjector")
+
+func metricsService(name, app string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name + "-metrics",
+			Labels: map[string]string{
+				"app":                         app,
+				"app.kubernetes.io/component": MetricsComponentLabel,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{
+					Name:       MetricsPortName,
+					Port:       MetricsPort.IntVal,
+					Protocol:   corev1.ProtocolTCP,
+					TargetPort: MetricsPort,
+				},
+			},
+			Selector: map[string]string{
+				"app": app,
+			},
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+}
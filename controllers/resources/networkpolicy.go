@@ -0,0 +1,154 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var protocolTCP = corev1.ProtocolTCP
+var protocolUDP = corev1.ProtocolUDP
+
+var webhookSecurePort = intstr.FromInt32(10250)
+var apiServerPort = intstr.FromInt32(443)
+var apiServerAltPort = intstr.FromInt32(6443)
+var dnsPort = intstr.FromInt32(53)
+var dnsAltPort = intstr.FromInt32(5353)
+var acmeSolverPort = intstr.FromInt32(8089)
+
+// MetricsPort is the port the operands serve their prometheus metrics on
+var MetricsPort = intstr.FromInt32(9402)
+
+// AcmeSolverPodLabels are the labels of the pods cert-manager-controller
+// creates to solve ACME HTTP01 challenges
+var AcmeSolverPodLabels = map[string]string{
+	"acme.cert-manager.io/http01-solver": "true",
+}
+
+// APIServerEgressPorts are the ports the operands use to reach the API server
+var APIServerEgressPorts = []networkingv1.NetworkPolicyPort{
+	{Protocol: &protocolTCP, Port: &apiServerPort},
+	{Protocol: &protocolTCP, Port: &apiServerAltPort},
+}
+
+// DNSEgressPorts are the ports the operands use to resolve names
+var DNSEgressPorts = []networkingv1.NetworkPolicyPort{
+	{Protocol: &protocolUDP, Port: &dnsPort},
+	{Protocol: &protocolTCP, Port: &dnsPort},
+	{Protocol: &protocolUDP, Port: &dnsAltPort},
+	{Protocol: &protocolTCP, Port: &dnsAltPort},
+}
+
+// ControllerNetworkPolicy is the NetworkPolicy definition for
+// cert-manager-controller. The API server, external endpoints and metrics
+// rules are filled in from the CR
+var ControllerNetworkPolicy = &networkingv1.NetworkPolicy{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:   CertManagerControllerName,
+		Labels: ControllerLabelMap,
+	},
+	Spec: networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"app": "ibm-cert-manager-controller",
+			},
+		},
+		PolicyTypes: []networkingv1.PolicyType{
+			networkingv1.PolicyTypeIngress,
+			networkingv1.PolicyTypeEgress,
+		},
+		Egress: []networkingv1.NetworkPolicyEgressRule{
+			{
+				Ports: DNSEgressPorts,
+			},
+			{
+				Ports: []networkingv1.NetworkPolicyPort{
+					{Protocol: &protocolTCP, Port: &acmeSolverPort},
+				},
+				To: []networkingv1.NetworkPolicyPeer{
+					{
+						NamespaceSelector: &metav1.LabelSelector{},
+						PodSelector: &metav1.LabelSelector{
+							MatchLabels: AcmeSolverPodLabels,
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+// WebhookNetworkPolicy is the NetworkPolicy definition for
+// cert-manager-webhook. The source of the API server calls can not be selected
+// by labels, so the secure port is open to any source
+var WebhookNetworkPolicy = &networkingv1.NetworkPolicy{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:   CertManagerWebhookName,
+		Labels: WebhookLabelMap,
+	},
+	Spec: networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"app": "ibm-cert-manager-webhook",
+			},
+		},
+		PolicyTypes: []networkingv1.PolicyType{
+			networkingv1.PolicyTypeIngress,
+			networkingv1.PolicyTypeEgress,
+		},
+		Ingress: []networkingv1.NetworkPolicyIngressRule{
+			{
+				Ports: []networkingv1.NetworkPolicyPort{
+					{Protocol: &protocolTCP, Port: &webhookSecurePort},
+				},
+			},
+		},
+		Egress: []networkingv1.NetworkPolicyEgressRule{
+			{
+				Ports: DNSEgressPorts,
+			},
+		},
+	},
+}
+
+// CainjectorNetworkPolicy is the NetworkPolicy definition for
+// cert-manager-cainjector
+var CainjectorNetworkPolicy = &networkingv1.NetworkPolicy{
+	ObjectMeta: metav1.ObjectMeta{
+		Name:   CertManagerCainjectorName,
+		Labels: CainjectorLabelMap,
+	},
+	Spec: networkingv1.NetworkPolicySpec{
+		PodSelector: metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				"app": "ibm-cert-manager-cainjector",
+			},
+		},
+		PolicyTypes: []networkingv1.PolicyType{
+			networkingv1.PolicyTypeIngress,
+			networkingv1.PolicyTypeEgress,
+		},
+		Egress: []networkingv1.NetworkPolicyEgressRule{
+			{
+				Ports: DNSEgressPorts,
+			},
+		},
+	},
+}
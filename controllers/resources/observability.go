@@ -0,0 +1,68 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package resources
+
+// Telemetry providers supported in the observability spec
+const (
+	ProviderOTel    = "otel"
+	ProviderInstana = "instana"
+)
+
+// Sources of the agent host address
+const (
+	AgentHostSourceHostIP = "HostIP"
+	AgentHostSourcePodIP  = "PodIP"
+)
+
+// Environment variables read by the OpenTelemetry SDKs
+const (
+	OTelServiceNameEnv        = "OTEL_SERVICE_NAME"
+	OTelEndpointEnv           = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	OTelProtocolEnv           = "OTEL_EXPORTER_OTLP_PROTOCOL"
+	OTelTracesSamplerEnv      = "OTEL_TRACES_SAMPLER"
+	OTelTracesSamplerArgEnv   = "OTEL_TRACES_SAMPLER_ARG"
+	OTelResourceAttributesEnv = "OTEL_RESOURCE_ATTRIBUTES"
+	OTelAgentHostEnv          = "OTEL_AGENT_HOST"
+	InstanaAgentHostEnv       = "INSTANA_AGENT_HOST"
+)
+
+// OTelRatioSampler is the sampler used when a sampling ratio is set
+const OTelRatioSampler = "parentbased_traceidratio"
+
+// InstanaOTLPPort is the port the Instana agent receives OTLP data on
+const InstanaOTLPPort = "4317"
+
+// InstanaOperands lists the operands the address of the Instana agent is
+// injected into, as EnableInstanaMetricCollection always did
+var InstanaOperands = map[string]bool{
+	CertManagerControllerName: true,
+	CertManagerWebhookName:    true,
+	CertManagerCainjectorName: true,
+}
+
+// TelemetryOperands lists the operands which read the OTEL_* environment
+// variables. None of the operand images of ControllerImageVersion and
+// WebhookImageVersion embeds an OpenTelemetry SDK, add an operand once its
+// image does
+var TelemetryOperands = map[string]bool{}
+
+// AgentHostFieldPaths maps an agent host source to the pod field it is read
+// from
+var AgentHostFieldPaths = map[string]string{
+	AgentHostSourceHostIP: "status.hostIP",
+	AgentHostSourcePodIP:  "status.podIP",
+}
@@ -0,0 +1,68 @@
+//
+// Copyright 2022 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ProfileSmall is the resource profile for clusters with up to a few thousand
+// Certificates
+const ProfileSmall = "small"
+
+// ProfileMedium is the resource profile for clusters with up to ten thousand
+// Certificates
+const ProfileMedium = "medium"
+
+// ProfileLarge is the resource profile for clusters with tens of thousands of
+// Certificates
+const ProfileLarge = "large"
+
+// ProfileResources maps each resource profile to the resource requirements of
+// every operand, keyed by the operand name
+var ProfileResources = map[string]map[string]corev1.ResourceRequirements{
+	ProfileSmall: {
+		CertManagerControllerName: requirements("80m", "1010Mi", "20m", "230Mi", "510Mi"),
+		CertManagerCainjectorName: requirements("100m", "1000Mi", "30m", "500Mi", "256Mi"),
+		CertManagerWebhookName:    requirements("60m", "100Mi", "30m", "40Mi", "256Mi"),
+	},
+	ProfileMedium: {
+		CertManagerControllerName: requirements("500m", "2Gi", "100m", "512Mi", "510Mi"),
+		CertManagerCainjectorName: requirements("500m", "2Gi", "100m", "1Gi", "256Mi"),
+		CertManagerWebhookName:    requirements("200m", "256Mi", "50m", "64Mi", "256Mi"),
+	},
+	ProfileLarge: {
+		CertManagerControllerName: requirements("1", "4Gi", "250m", "1Gi", "1Gi"),
+		CertManagerCainjectorName: requirements("1", "4Gi", "250m", "2Gi", "256Mi"),
+		CertManagerWebhookName:    requirements("500m", "512Mi", "100m", "128Mi", "256Mi"),
+	},
+}
+
+func requirements(limitCPU, limitMemory, requestCPU, requestMemory, requestEphemeral string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(limitCPU),
+			corev1.ResourceMemory: resource.MustParse(limitMemory),
+		},
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse(requestCPU),
+			corev1.ResourceMemory:           resource.MustParse(requestMemory),
+			corev1.ResourceEphemeralStorage: resource.MustParse(requestEphemeral),
+		},
+	}
+}
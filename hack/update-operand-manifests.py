@@ -0,0 +1,167 @@
+#!/usr/bin/env python3
+#
+# Copyright 2022 IBM Corporation
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+# http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+
+"""
+Extract the RBAC and the webhook objects of the operands from the upstream
+cert-manager yaml manifests into the manifests embedded in the operator.
+RBAC, written to rbac/:
+* Keeps only ServiceAccounts, Roles, RoleBindings, ClusterRoles and
+  ClusterRoleBindings
+* Renames the objects from cert-manager to ibm-cert-manager
+* Replaces the namespace with the {{ .Namespace }} template value
+The objects are written to one file per component deployed by the operator,
+named after the app.kubernetes.io/component label. The ClusterRoles
+aggregated into the built-in roles are written to aggregated.yaml, the
+objects of the other components, e.g. startupapicheck, are dropped.
+Webhook, written to webhook.yaml:
+* Keeps the Service, MutatingWebhookConfiguration and
+  ValidatingWebhookConfiguration of cert-manager-webhook
+* Replaces the names, namespace, labels, selector and CA injection with the
+  template values of the operator
+* Excludes the namespace of the operands from the validating webhook
+The result must be reviewed before being committed: the operator fails to
+start if the manifests are invalid.
+Usage:
+  hack/update-operand-manifests.py controllers/resources/manifests < cert-manager.yaml
+"""
+import os
+import sys
+
+import yaml
+
+rbac_kinds = ("ServiceAccount", "Role", "RoleBinding", "ClusterRole", "ClusterRoleBinding")
+upstream_namespace = "cert-manager"
+# components of cert-manager deployed by the operator
+deployed_components = ("controller", "cainjector", "webhook")
+aggregated_component = "aggregated"
+aggregate_label_prefix = "rbac.authorization.k8s.io/aggregate-to-"
+
+webhook_kinds = ("Service", "MutatingWebhookConfiguration", "ValidatingWebhookConfiguration")
+upstream_webhook_name = "cert-manager-webhook"
+# port cert-manager-webhook listens on in the deployment of the operator
+webhook_target_port = 10250
+
+
+def rename(name):
+    """
+    Prefix the name of an upstream object with ibm-
+    """
+    if name.startswith("cert-manager"):
+        return "ibm-" + name
+    return name
+
+
+def convert(obj):
+    """
+    Rename and template an upstream RBAC object
+    """
+    metadata = obj["metadata"]
+    metadata["name"] = rename(metadata["name"])
+    if metadata.get("namespace") == upstream_namespace:
+        metadata["namespace"] = "{{ .Namespace }}"
+    if "roleRef" in obj:
+        obj["roleRef"]["name"] = rename(obj["roleRef"]["name"])
+    for subject in obj.get("subjects", []):
+        subject["name"] = rename(subject["name"])
+        if subject.get("namespace") == upstream_namespace:
+            subject["namespace"] = "{{ .Namespace }}"
+    return obj
+
+
+def component_of(obj):
+    """
+    Return the file an upstream RBAC object is written to, or None if the
+    operator does not deploy its component
+    """
+    labels = obj["metadata"].get("labels") or {}
+    if obj["kind"] == "ClusterRole" and any(k.startswith(aggregate_label_prefix) for k in labels):
+        return aggregated_component
+    component = labels.get("app.kubernetes.io/component", "controller")
+    if component not in deployed_components:
+        return None
+    return component
+
+
+def convert_webhook(obj):
+    """
+    Template an upstream object of cert-manager-webhook with the values of
+    the operator
+    """
+    kind = obj["kind"]
+    if kind == "Service":
+        obj["metadata"] = {
+            "labels": {"app": "{{ .WebhookApp }}"},
+            "name": "{{ .WebhookName }}",
+            "namespace": "{{ .Namespace }}",
+        }
+        obj["spec"]["selector"] = {"app": "{{ .WebhookApp }}"}
+        for port in obj["spec"].get("ports", []):
+            port["targetPort"] = webhook_target_port
+        return obj
+
+    obj["metadata"] = {
+        "annotations": {"cert-manager.io/inject-ca-from-secret": "{{ .Namespace }}/{{ .WebhookServingSecret }}"},
+        "name": "{{ .WebhookName }}",
+    }
+    for webhook in obj.get("webhooks", []):
+        service = webhook["clientConfig"]["service"]
+        service["name"] = "{{ .WebhookName }}"
+        service["namespace"] = "{{ .Namespace }}"
+        if kind == "ValidatingWebhookConfiguration":
+            selector = webhook.setdefault("namespaceSelector", {})
+            expressions = selector.setdefault("matchExpressions", [])
+            own_namespace = {"key": "name", "operator": "NotIn", "values": ["{{ .Namespace }}"]}
+            if own_namespace not in expressions:
+                expressions.append(own_namespace)
+    return obj
+
+
+def main(outdir):
+    components = {}
+    webhook_objs = []
+    for obj in yaml.safe_load_all(sys.stdin):
+        if not obj:
+            continue
+        if obj.get("kind") in webhook_kinds and obj["metadata"]["name"] == upstream_webhook_name:
+            webhook_objs.append(convert_webhook(obj))
+            continue
+        if obj.get("kind") not in rbac_kinds:
+            continue
+        component = component_of(obj)
+        if component is None:
+            print("skipped {} {}".format(obj["kind"], obj["metadata"]["name"]), file=sys.stderr)
+            continue
+        components.setdefault(component, []).append(convert(obj))
+
+    for component, objs in components.items():
+        path = os.path.join(outdir, "rbac", component + ".yaml")
+        with open(path, "w") as f:
+            yaml.safe_dump_all(objs, f, default_flow_style=False, width=1000)
+        print("wrote {} objects to {}".format(len(objs), path), file=sys.stderr)
+
+    webhook_objs.sort(key=lambda obj: webhook_kinds.index(obj["kind"]))
+    path = os.path.join(outdir, "webhook.yaml")
+    with open(path, "w") as f:
+        yaml.safe_dump_all(webhook_objs, f, default_flow_style=False, explicit_start=True, width=1000)
+    print("wrote {} objects to {}".format(len(webhook_objs), path), file=sys.stderr)
+
+
+if __name__ == "__main__":
+    if len(sys.argv) != 2:
+        print(__doc__, file=sys.stderr)
+        sys.exit(1)
+    main(sys.argv[1])
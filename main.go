@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"os"
 
@@ -26,6 +27,7 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	admRegv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextensionv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -34,9 +36,12 @@ import (
 	apiRegv1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	res "github.com/ibm/ibm-cert-manager-operator/v4/controllers/resources"
 
@@ -66,25 +71,51 @@ func init() {
 }
 
 func main() {
+	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var logFormat string
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&logFormat, "log-format", "text",
+		"The log format of the operator, either text or json. "+
+			"json switches the logger to its production configuration.")
 	opts := zap.Options{
 		Development: true,
 	}
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
 
+	if logFormat == "json" {
+		opts.Development = false
+	}
+
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	// the certificate of the policy webhook is read from its serving secret,
+	// the reader is set once the manager is created
+	policyWebhookCert := &operatorcontrollers.PolicyWebhookCertificate{NS: res.DeployNamespace}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
+		Metrics: metricsserver.Options{
+			BindAddress: metricsAddr,
+		},
 		Cache: cache.Options{
 			ReaderFailOnMissingInformer: true,
+			// only the secrets watched for rollouts are cached, the other
+			// secrets are read from the API server
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Secret{}: {Label: operatorcontrollers.WatchedSecretsSelector()},
+			},
 		},
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port:    res.PolicyWebhookPort,
+			TLSOpts: []func(*tls.Config){policyWebhookCert.TLSConfig},
+		}),
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "1557e857.ibm.com",
@@ -114,6 +145,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := res.LoadManifests(); err != nil {
+		setupLog.Error(err, "invalid operand manifests")
+		os.Exit(1)
+	}
+
 	kubeclient, _ := kubernetes.NewForConfig(mgr.GetConfig())
 	apiextclient, _ := apiextensionclientset.NewForConfig(mgr.GetConfig())
 	if err = (&operatorcontrollers.CertManagerReconciler{
@@ -128,6 +164,54 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "CertManager")
 		os.Exit(1)
 	}
+	tlsSecrets, err := operatorcontrollers.NewTLSSecretCache(mgr)
+	if err != nil {
+		setupLog.Error(err, "unable to create the cache of TLS secrets")
+		os.Exit(1)
+	}
+	if err = (&operatorcontrollers.CertRefreshReconciler{
+		Client:     mgr.GetClient(),
+		Reader:     mgr.GetAPIReader(),
+		Recorder:   mgr.GetEventRecorderFor("ibm-cert-manager-operator"),
+		TLSSecrets: tlsSecrets,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CertRefresh")
+		os.Exit(1)
+	}
+	if err = (&operatorcontrollers.SecretRolloutReconciler{
+		Client:   mgr.GetClient(),
+		Recorder: mgr.GetEventRecorderFor("ibm-cert-manager-operator"),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SecretRollout")
+		os.Exit(1)
+	}
+	if err = (&operatorcontrollers.InventoryReconciler{
+		Client:     mgr.GetClient(),
+		Reader:     mgr.GetAPIReader(),
+		NS:         res.DeployNamespace,
+		TLSSecrets: tlsSecrets,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Inventory")
+		os.Exit(1)
+	}
+	policyWebhookCert.Reader = mgr.GetAPIReader()
+	if err = (&operatorcontrollers.CertificatePolicyReconciler{
+		Client: mgr.GetClient(),
+		Reader: mgr.GetAPIReader(),
+		Scheme: mgr.GetScheme(),
+		NS:     res.DeployNamespace,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "CertificatePolicy")
+		os.Exit(1)
+	}
+	if err = (&operatorcontrollers.ExpiryMonitor{
+		Client:     mgr.GetClient(),
+		Recorder:   mgr.GetEventRecorderFor("ibm-cert-manager-operator"),
+		TLSSecrets: tlsSecrets,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create runnable", "runnable", "ExpiryMonitor")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {